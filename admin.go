@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+)
+
+// authenticateAdmin checks that the request carries the access token of an
+// admin. If it doesn't, the error response has already been written and ok
+// is false.
+func (cfg *apiConfig) authenticateAdmin(w http.ResponseWriter, r *http.Request) (userID uuid.UUID, ok bool) {
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return uuid.Nil, false
+	}
+	userID, err = auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return uuid.Nil, false
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find user", err)
+		return uuid.Nil, false
+	}
+	if !user.IsAdmin {
+		respondWithError(w, http.StatusForbidden, "Admin access required", nil)
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
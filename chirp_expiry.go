@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/validation"
+)
+
+const (
+	minChirpLifetime    = time.Minute
+	maxChirpLifetime    = 365 * 24 * time.Hour
+	maxAutoDeleteDays   = 3650
+	reapChirpsBatchSize = 100
+)
+
+// parseExpiry works out when a chirp that opens at opensAt expires, from
+// either an expires_at time or a ttl_seconds counted from opensAt. A chirp
+// with neither never expires.
+func parseExpiry(expiresAt *time.Time, ttlSeconds *int64, opensAt time.Time) (sql.NullTime, error) {
+	switch {
+	case expiresAt != nil && ttlSeconds != nil:
+		return sql.NullTime{}, validation.FieldErrors{{
+			Field:   "ttl_seconds",
+			Code:    "invalid",
+			Message: "Give either expires_at or ttl_seconds, not both",
+		}}
+	case ttlSeconds != nil:
+		// Check the range before converting, which could overflow.
+		if *ttlSeconds <= 0 {
+			return sql.NullTime{}, validation.FieldErrors{{
+				Field:   "ttl_seconds",
+				Code:    "too_soon",
+				Message: "A chirp must live for at least a minute",
+			}}
+		}
+		if *ttlSeconds > int64(maxChirpLifetime/time.Second) {
+			return sql.NullTime{}, validation.FieldErrors{{
+				Field:   "ttl_seconds",
+				Code:    "too_far",
+				Message: "A chirp can live for at most a year",
+			}}
+		}
+		t := opensAt.Add(time.Duration(*ttlSeconds) * time.Second)
+		expiresAt = &t
+	case expiresAt == nil:
+		return sql.NullTime{}, nil
+	}
+
+	field := "expires_at"
+	if ttlSeconds != nil {
+		field = "ttl_seconds"
+	}
+	if expiresAt.Before(opensAt.Add(minChirpLifetime)) {
+		return sql.NullTime{}, validation.FieldErrors{{
+			Field:   field,
+			Code:    "too_soon",
+			Message: "A chirp must live for at least a minute",
+		}}
+	}
+	if expiresAt.After(opensAt.Add(maxChirpLifetime)) {
+		return sql.NullTime{}, validation.FieldErrors{{
+			Field:   field,
+			Code:    "too_far",
+			Message: "A chirp can live for at most a year",
+		}}
+	}
+	return sql.NullTime{Time: expiresAt.UTC(), Valid: true}, nil
+}
+
+// reapChirps deletes chirps that have expired, and chirps older than their
+// author's auto-delete setting, in batches. Expired chirps already drop out
+// of reads when they expire; this clears them out of the database. Rows
+// another instance is already reaping are skipped rather than waited for.
+func (cfg *apiConfig) reapChirps(ctx context.Context) error {
+	if err := cfg.reapChirpsFrom(ctx, "expired", (*database.Queries).GetExpiredChirps); err != nil {
+		return err
+	}
+	return cfg.reapChirpsFrom(ctx, "auto-deleted", (*database.Queries).GetAutoDeleteChirps)
+}
+
+// reapChirpsFrom deletes the chirps fetch finds, a batch at a time, until
+// it comes up short.
+func (cfg *apiConfig) reapChirpsFrom(ctx context.Context, kind string, fetch func(*database.Queries, context.Context, int32) ([]database.Chirp, error)) error {
+	for {
+		reaped, err := cfg.reapChirpBatch(ctx, fetch)
+		if err != nil {
+			return err
+		}
+		if reaped > 0 {
+			log.Printf("Deleted %d %s chirps", reaped, kind)
+		}
+		if reaped < reapChirpsBatchSize {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) reapChirpBatch(ctx context.Context, fetch func(*database.Queries, context.Context, int32) ([]database.Chirp, error)) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirps, err := fetch(qtx, ctx, reapChirpsBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	var blobKeys []string
+	for _, chirp := range chirps {
+		keys, err := deleteChirpNow(ctx, qtx, chirp)
+		if err != nil {
+			return 0, err
+		}
+		blobKeys = append(blobKeys, keys...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	cfg.deleteBlobs(blobKeys...)
+	return len(chirps), nil
+}
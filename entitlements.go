@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/subscription"
+)
+
+const (
+	planFree      = "free"
+	planChirpyRed = "chirpy_red"
+)
+
+// planLimits are the usage limits that depend on a user's plan.
+type planLimits struct {
+	maxChirpLength     int
+	maxUploadBytes     int64
+	chirpEditWindow    time.Duration
+	maxPinnedChirps    int
+	maxBookmarkFolders int
+}
+
+var (
+	freePlanLimits = planLimits{
+		maxChirpLength:     140,
+		maxUploadBytes:     5 << 20,
+		chirpEditWindow:    15 * time.Minute,
+		maxPinnedChirps:    1,
+		maxBookmarkFolders: 0,
+	}
+	chirpyRedPlanLimits = planLimits{
+		maxChirpLength:     280,
+		maxUploadBytes:     20 << 20,
+		chirpEditWindow:    time.Hour,
+		maxPinnedChirps:    5,
+		maxBookmarkFolders: 50,
+	}
+)
+
+// Entitlements answers what a user's plan lets them do. The plan comes from
+// the user's current subscription, and lapses the moment the subscription
+// stops granting it, even before expireLapsedSubscriptions catches up.
+type Entitlements struct {
+	db    *database.Queries
+	plans map[string]planLimits
+}
+
+// plan returns the plan userID is on.
+func (e *Entitlements) plan(ctx context.Context, userID uuid.UUID) (string, error) {
+	sub, err := e.db.GetCurrentSubscriptionByUserID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return planFree, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("couldn't get subscription: %w", err)
+	}
+	if !newSubscriptionState(sub).EntitledAt(time.Now()) {
+		return planFree, nil
+	}
+	return sub.Plan, nil
+}
+
+// limits returns the usage limits of the plan userID is on.
+func (e *Entitlements) limits(ctx context.Context, userID uuid.UUID) (planLimits, error) {
+	plan, err := e.plan(ctx, userID)
+	if err != nil {
+		return planLimits{}, err
+	}
+	limits, ok := e.plans[plan]
+	if !ok {
+		return planLimits{}, fmt.Errorf("unknown plan %q", plan)
+	}
+	return limits, nil
+}
+
+// isChirpyRed reports whether userID currently has Chirpy Red.
+func (e *Entitlements) isChirpyRed(ctx context.Context, userID uuid.UUID) (bool, error) {
+	plan, err := e.plan(ctx, userID)
+	return plan == planChirpyRed, err
+}
+
+// newSubscriptionState picks out what decides whether a subscription
+// grants its plan.
+func newSubscriptionState(sub database.Subscription) subscription.Subscription {
+	return subscription.Subscription{
+		Status:             sub.Status,
+		CurrentPeriodStart: sub.CurrentPeriodStart,
+		CurrentPeriodEnd:   sub.CurrentPeriodEnd,
+		TrialEndsAt:        sub.TrialEndsAt.Time,
+		GracePeriodEndsAt:  sub.GracePeriodEndsAt,
+		CanceledAt:         sub.CanceledAt.Time,
+	}
+}
@@ -8,4 +8,7 @@ require (
 	github.com/lib/pq v1.10.9
 	golang.org/x/crypto v0.31.0
 	github.com/golang-jwt/jwt/v5 v5.2.1
+	golang.org/x/image v0.30.0
+	golang.org/x/text v0.28.0
+	github.com/rivo/uniseg v0.4.7
 )
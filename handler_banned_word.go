@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/profanity"
+)
+
+type BannedWord struct {
+	Word      string           `json:"word"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Action    profanity.Action `json:"action"`
+}
+
+// reloadBannedWords swaps in a filter built from the current word list.
+// Admin changes reload it straight away; the periodic reload picks up
+// changes made through other instances.
+func (cfg *apiConfig) reloadBannedWords(ctx context.Context) error {
+	dbWords, err := cfg.db.GetBannedWords(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := make([]profanity.Rule, len(dbWords))
+	for i, dbWord := range dbWords {
+		rules[i] = profanity.Rule{Word: dbWord.Word, Action: profanity.Action(dbWord.Action)}
+	}
+	cfg.profanityFilter.Store(profanity.NewFilter(rules))
+	return nil
+}
+
+func (cfg *apiConfig) handlerGetBannedWords(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.authenticateAdmin(w, r); !ok {
+		return
+	}
+
+	dbWords, err := cfg.db.GetBannedWords(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get banned words", err)
+		return
+	}
+
+	words := make([]BannedWord, len(dbWords))
+	for i, dbWord := range dbWords {
+		words[i] = newBannedWord(dbWord)
+	}
+	respondWithJSON(w, http.StatusOK, words)
+}
+
+func (cfg *apiConfig) handlerPutBannedWord(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Action string `json:"action"`
+	}
+
+	if _, ok := cfg.authenticateAdmin(w, r); !ok {
+		return
+	}
+
+	word := profanity.Normalize(r.PathValue("word"))
+	if word == "" || strings.ContainsFunc(word, unicode.IsSpace) {
+		respondWithError(w, http.StatusBadRequest, "Invalid word", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	action, err := profanity.ParseAction(params.Action)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Action must be mask, hold or reject", err)
+		return
+	}
+
+	dbWord, err := cfg.db.UpsertBannedWord(r.Context(), database.UpsertBannedWordParams{
+		Word:   word,
+		Action: string(action),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save banned word", err)
+		return
+	}
+	if err := cfg.reloadBannedWords(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload banned words", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newBannedWord(dbWord))
+}
+
+func (cfg *apiConfig) handlerDeleteBannedWord(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.authenticateAdmin(w, r); !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBannedWord(r.Context(), profanity.Normalize(r.PathValue("word")))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete banned word", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Word isn't banned", nil)
+		return
+	}
+	if err := cfg.reloadBannedWords(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload banned words", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newBannedWord(dbWord database.BannedWord) BannedWord {
+	return BannedWord{
+		Word:      dbWord.Word,
+		CreatedAt: dbWord.CreatedAt,
+		UpdatedAt: dbWord.UpdatedAt,
+		Action:    profanity.Action(dbWord.Action),
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+)
+
+// BlockListEntry is one account in the user's block or mute list.
+type BlockListEntry struct {
+	ID        uuid.UUID `json:"id"`
+	Handle    string    `json:"handle,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handlerBlockUser blocks a user. Neither side can see the other's chirps
+// from then on, so they also stop following each other.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	targetID, userID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	userExists, err := qtx.UserExists(r.Context(), targetID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check if user exists", err)
+		return
+	}
+	if !userExists {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	if _, err := qtx.CreateBlock(r.Context(), database.CreateBlockParams{
+		BlockerID: userID,
+		BlockedID: targetID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't block user", err)
+		return
+	}
+
+	for _, follow := range []database.DeleteFollowParams{
+		{FollowerID: userID, FolloweeID: targetID},
+		{FollowerID: targetID, FolloweeID: userID},
+	} {
+		removed, err := qtx.DeleteFollow(r.Context(), follow)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't remove follow", err)
+			return
+		}
+		if removed == 0 {
+			continue
+		}
+		if err := qtx.DecrementFollowCounts(r.Context(), database.DecrementFollowCountsParams(follow)); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update follow counts", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	targetID, userID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := cfg.db.DeleteBlock(r.Context(), database.DeleteBlockParams{
+		BlockerID: userID,
+		BlockedID: targetID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unblock user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerMuteUser hides a user's chirps from the lists the muter reads. The
+// muted user isn't told and can still see and reply to the muter's chirps.
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	targetID, userID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return
+	}
+
+	userExists, err := cfg.db.UserExists(r.Context(), targetID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check if user exists", err)
+		return
+	}
+	if !userExists {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	if _, err := cfg.db.CreateMute(r.Context(), database.CreateMuteParams{
+		MuterID: userID,
+		MutedID: targetID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	targetID, userID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := cfg.db.DeleteMute(r.Context(), database.DeleteMuteParams{
+		MuterID: userID,
+		MutedID: targetID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unmute user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseUserRelationshipRequest reads the target user from the path and the
+// caller from their token, for blocking and muting. It responds with an
+// error itself and returns false if either is missing or they're the same.
+func (cfg *apiConfig) parseUserRelationshipRequest(w http.ResponseWriter, r *http.Request) (targetID, userID uuid.UUID, ok bool) {
+	targetIDString := r.PathValue("userID")
+	targetID, err := uuid.Parse(targetIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+	userID, err = auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+
+	if targetID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't do that to yourself", nil)
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+	return targetID, userID, true
+}
+
+func (cfg *apiConfig) handlerGetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	cfg.respondWithBlockList(w, r, func(userID uuid.UUID, page pageParams) ([]BlockListEntry, error) {
+		rows, err := cfg.db.GetBlockedUsers(r.Context(), database.GetBlockedUsersParams{
+			UserID:          userID,
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			Limit:           page.fetchLimit(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]BlockListEntry, len(rows))
+		for i, row := range rows {
+			entries[i] = BlockListEntry{ID: row.ID, Handle: row.Handle.String, CreatedAt: row.CreatedAt}
+		}
+		return entries, nil
+	})
+}
+
+func (cfg *apiConfig) handlerGetMutedUsers(w http.ResponseWriter, r *http.Request) {
+	cfg.respondWithBlockList(w, r, func(userID uuid.UUID, page pageParams) ([]BlockListEntry, error) {
+		rows, err := cfg.db.GetMutedUsers(r.Context(), database.GetMutedUsersParams{
+			UserID:          userID,
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			Limit:           page.fetchLimit(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]BlockListEntry, len(rows))
+		for i, row := range rows {
+			entries[i] = BlockListEntry{ID: row.ID, Handle: row.Handle.String, CreatedAt: row.CreatedAt}
+		}
+		return entries, nil
+	})
+}
+
+// respondWithBlockList does the work shared by the block and mute lists,
+// which are private to the user; list loads one page of entries.
+func (cfg *apiConfig) respondWithBlockList(w http.ResponseWriter, r *http.Request, list func(uuid.UUID, pageParams) ([]BlockListEntry, error)) {
+	type response struct {
+		Users      []BlockListEntry `json:"users"`
+		NextCursor string           `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	entries, err := list(userID, page)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get users", err)
+		return
+	}
+
+	entries, nextCursor := nextPage(entries, page.limit, func(entry BlockListEntry) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: entry.CreatedAt, ID: entry.ID}
+	})
+
+	respondWithJSON(w, http.StatusOK, response{
+		Users:      entries,
+		NextCursor: nextCursor,
+	})
+}
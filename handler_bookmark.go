@@ -0,0 +1,489 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+	"github.com/katsuikeda/chirpy/internal/validation"
+)
+
+const maxBookmarkFolderNameLength = 50
+
+type BookmarkFolder struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+// handlerBookmarkChirp saves a chirp for later. Bookmarks are private to the
+// user. Chirpy Red users can file the bookmark in one of their folders by
+// passing folder_id; bookmarking a chirp again moves it to that folder, or
+// out of any folder when folder_id is left out. Bookmarking a rechirp
+// bookmarks the chirp it reshares.
+func (cfg *apiConfig) handlerBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		FolderID *uuid.UUID `json:"folder_id"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	// The body is optional; an empty one leaves the bookmark unfiled.
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	chirp, err := getOriginalChirp(r.Context(), cfg.db, chirpID, userID)
+	if err == nil && chirp.DeletedAt.Valid {
+		err = errors.New("chirp is in the trash")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp to bookmark", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Folders are created, filed into and deleted with the user locked, so
+	// the folder limit holds and a folder can't go away while a bookmark is
+	// being filed in it.
+	if _, err := qtx.GetUserByIDForUpdate(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	folderID := uuid.NullUUID{}
+	if params.FolderID != nil {
+		limits, err := cfg.entitlements.limits(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get plan limits", err)
+			return
+		}
+		if limits.maxBookmarkFolders == 0 {
+			respondWithError(w, http.StatusForbidden, "Bookmark folders need Chirpy Red", nil)
+			return
+		}
+		folder, err := qtx.GetBookmarkFolderByID(r.Context(), *params.FolderID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && folder.UserID != userID) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find bookmark folder", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get bookmark folder", err)
+			return
+		}
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	if err := qtx.CreateBookmark(r.Context(), database.CreateBookmarkParams{
+		UserID:   userID,
+		ChirpID:  chirp.ID,
+		FolderID: folderID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't bookmark chirp", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUnbookmarkChirp removes a bookmark. Removing one that isn't there
+// isn't an error. As with bookmarking, a rechirp stands for the chirp it
+// reshares.
+func (cfg *apiConfig) handlerUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	// Fall back to the ID as given, so a bookmark can still be removed
+	// after its chirp stops being visible.
+	if chirp, err := getOriginalChirp(r.Context(), cfg.db, chirpID, userID); err == nil {
+		chirpID = chirp.ID
+	}
+
+	if _, err := cfg.db.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove bookmark", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetBookmarks lists the user's bookmarked chirps, most recently
+// bookmarked first, optionally only those in the folder given by
+// ?folder_id=. Chirps the user can no longer see are left out.
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	folderID := uuid.NullUUID{}
+	if s := r.URL.Query().Get("folder_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid folder ID", err)
+			return
+		}
+		folder, err := cfg.db.GetBookmarkFolderByID(r.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && folder.UserID != userID) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find bookmark folder", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get bookmark folder", err)
+			return
+		}
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetBookmarkedChirps(r.Context(), database.GetBookmarkedChirpsParams{
+		UserID:          userID,
+		FolderID:        folderID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get bookmarks", err)
+		return
+	}
+
+	rows, nextCursor := nextPage(rows, page.limit, bookmarkCursor)
+	dbChirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		dbChirps[i] = row.Chirp
+	}
+	chirps, err := cfg.populateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerGetBookmarkFolders(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	dbFolders, err := cfg.db.GetBookmarkFolders(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get bookmark folders", err)
+		return
+	}
+
+	folders := make([]BookmarkFolder, len(dbFolders))
+	for i, dbFolder := range dbFolders {
+		folders[i] = newBookmarkFolder(dbFolder)
+	}
+	respondWithJSON(w, http.StatusOK, folders)
+}
+
+// handlerCreateBookmarkFolder creates a named folder for bookmarks. Folders
+// are a Chirpy Red feature. Users who have let Red lapse keep the folders
+// they made and can still list, rename and delete them.
+func (cfg *apiConfig) handlerCreateBookmarkFolder(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	name, err := parseBookmarkFolderName(params.Name)
+	if err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+
+	limits, err := cfg.entitlements.limits(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get plan limits", err)
+		return
+	}
+	maxFolders := limits.maxBookmarkFolders
+	if maxFolders == 0 {
+		respondWithError(w, http.StatusForbidden, "Bookmark folders need Chirpy Red", nil)
+		return
+	}
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// See handlerBookmarkChirp.
+	if _, err := qtx.GetUserByIDForUpdate(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	count, err := qtx.CountBookmarkFolders(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count bookmark folders", err)
+		return
+	}
+	if count >= int64(maxFolders) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("You can have at most %d bookmark folders", maxFolders), nil)
+		return
+	}
+
+	folder, err := qtx.CreateBookmarkFolder(r.Context(), database.CreateBookmarkFolderParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already have a bookmark folder with that name", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create bookmark folder", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newBookmarkFolder(folder))
+}
+
+func (cfg *apiConfig) handlerRenameBookmarkFolder(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	folderID, err := uuid.Parse(r.PathValue("folderID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid folder ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	name, err := parseBookmarkFolderName(params.Name)
+	if err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+
+	folder, err := cfg.db.RenameBookmarkFolder(r.Context(), database.RenameBookmarkFolderParams{
+		ID:     folderID,
+		UserID: userID,
+		Name:   name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find bookmark folder", err)
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already have a bookmark folder with that name", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rename bookmark folder", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newBookmarkFolder(folder))
+}
+
+// handlerDeleteBookmarkFolder deletes a folder. The bookmarks in it are
+// kept and become unfiled.
+func (cfg *apiConfig) handlerDeleteBookmarkFolder(w http.ResponseWriter, r *http.Request) {
+	folderID, err := uuid.Parse(r.PathValue("folderID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid folder ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// See handlerBookmarkChirp.
+	if _, err := qtx.GetUserByIDForUpdate(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	deleted, err := qtx.DeleteBookmarkFolder(r.Context(), database.DeleteBookmarkFolderParams{
+		ID:     folderID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete bookmark folder", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find bookmark folder", nil)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseBookmarkFolderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", validation.FieldErrors{{
+			Field:   "name",
+			Code:    "required",
+			Message: "name can't be empty",
+		}}
+	}
+	if utf8.RuneCountInString(name) > maxBookmarkFolderNameLength {
+		return "", validation.FieldErrors{{
+			Field:   "name",
+			Code:    "too_long",
+			Message: fmt.Sprintf("name can be at most %d characters", maxBookmarkFolderNameLength),
+		}}
+	}
+	return name, nil
+}
+
+func bookmarkCursor(row database.GetBookmarkedChirpsRow) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: row.BookmarkedAt, ID: row.Chirp.ID}
+}
+
+func newBookmarkFolder(dbFolder database.BookmarkFolder) BookmarkFolder {
+	return BookmarkFolder{
+		ID:        dbFolder.ID,
+		CreatedAt: dbFolder.CreatedAt,
+		UpdatedAt: dbFolder.UpdatedAt,
+		Name:      dbFolder.Name,
+	}
+}
@@ -1,31 +1,105 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
-	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/katsuikeda/chirpy/internal/auth"
 	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+	"github.com/katsuikeda/chirpy/internal/validation"
 )
 
+const (
+	chirpStatusPublished = "published"
+	// chirpStatusHeld chirps are only visible to their author until a
+	// moderator approves them.
+	chirpStatusHeld = "held"
+	// chirpStatusScheduled chirps are only visible to their author until
+	// their publish_at time.
+	chirpStatusScheduled = "scheduled"
+	// chirpStatusHidden chirps were hidden by a moderator and are only
+	// visible to their author.
+	chirpStatusHidden = "hidden"
+)
+
+// Who may read a chirp besides its author. The chirp_visible_to SQL
+// function enforces these on every read query.
+const (
+	chirpVisibilityPublic    = "public"
+	chirpVisibilityFollowers = "followers"
+	chirpVisibilityMentioned = "mentioned"
+	chirpVisibilityPrivate   = "private"
+)
+
+const maxContentWarningLength = 100
+
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID             uuid.UUID                  `json:"id"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+	PublishedAt    time.Time                  `json:"published_at"`
+	Body           string                     `json:"body"`
+	UserID         uuid.UUID                  `json:"user_id"`
+	InReplyTo      *uuid.UUID                 `json:"in_reply_to"`
+	ReplyCount     int32                      `json:"reply_count"`
+	RechirpOf      *Chirp                     `json:"rechirp_of,omitempty"`
+	QuotedChirp    *Chirp                     `json:"quoted_chirp,omitempty"`
+	RechirpCount   int32                      `json:"rechirp_count"`
+	QuoteCount     int32                      `json:"quote_count"`
+	Reactions      map[string]ReactionSummary `json:"reactions"`
+	Mentions       []Mention                  `json:"mentions"`
+	Media          []MediaAttachment          `json:"media"`
+	Status         string                     `json:"status,omitempty"`
+	Visibility     string                     `json:"visibility"`
+	PublishAt      *time.Time                 `json:"publish_at,omitempty"`
+	DeletedAt      *time.Time                 `json:"deleted_at,omitempty"`
+	Deleted        bool                       `json:"deleted,omitempty"`
+	Unavailable    bool                       `json:"unavailable,omitempty"`
+	MutedWord      string                     `json:"muted_word,omitempty"`
+	ContentWarning string                     `json:"content_warning,omitempty"`
+	Sensitive      bool                       `json:"sensitive"`
+	Collapsed      bool                       `json:"collapsed,omitempty"`
+	Pinned         bool                       `json:"pinned,omitempty"`
+	Bookmarked     bool                       `json:"bookmarked"`
+	Poll           *Poll                      `json:"poll,omitempty"`
+	ExpiresAt      *time.Time                 `json:"expires_at,omitempty"`
+
+	// collapsedBody keeps the body of a collapsed chirp for muted words to
+	// match against.
+	collapsedBody string
 }
 
-func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
-	type parameters struct {
-		Body string `json:"body"`
-	}
+// chirpInput is what a new chirp is made from, whether it's posted directly
+// or published from a draft.
+type chirpInput struct {
+	Body           string           `json:"body"`
+	InReplyTo      *uuid.UUID       `json:"in_reply_to"`
+	QuotedChirpID  *uuid.UUID       `json:"quoted_chirp_id"`
+	Media          []mediaParameter `json:"media"`
+	PublishAt      *time.Time       `json:"publish_at"`
+	Visibility     string           `json:"visibility"`
+	ContentWarning string           `json:"content_warning"`
+	Sensitive      bool             `json:"sensitive"`
+	Poll           *pollParameter   `json:"poll"`
+	ExpiresAt      *time.Time       `json:"expires_at"`
+	TTLSeconds     *int64           `json:"ttl_seconds"`
+}
+
+var (
+	errReplyTargetNotFound = errors.New("couldn't find chirp to reply to")
+	errQuoteTargetNotFound = errors.New("couldn't find chirp to quote")
+	errAccountSuspended    = errors.New("account is suspended")
+)
 
+func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
 	tokenString, err := auth.GetAccessToken(r.Header)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT in request header", err)
@@ -38,71 +112,332 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 	}
 
 	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
+	params := chirpInput{}
 	err = decoder.Decode(&params)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
 		return
 	}
 
-	cleanedBody, err := validateChirp(params.Body)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleanedBody,
-		UserID: userID,
-	})
+	chirp, err := cfg.createChirp(r.Context(), qtx, userID, params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		respondWithCreateChirpError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	},
-	)
+	respChirp, err := cfg.populateChirp(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, respChirp)
 }
 
-func validateChirp(body string) (string, error) {
-	const maxChirpLength = 140
+// createChirp validates input and stores it as a chirp by userID, along with
+// its hashtags, mentions, attachments and poll. It should run in a transaction,
+// which the caller commits. Problems with the input are returned as
+// validation.FieldErrors; see respondWithCreateChirpError for the rest.
+func (cfg *apiConfig) createChirp(ctx context.Context, q *database.Queries, userID uuid.UUID, input chirpInput) (database.Chirp, error) {
+	if err := validateMediaParameters(input.Media); err != nil {
+		return database.Chirp{}, err
+	}
+	draft := validation.ChirpDraft{Body: input.Body, MediaCount: len(input.Media)}
+	if err := cfg.validateChirp(ctx, userID, &draft); err != nil {
+		return database.Chirp{}, err
+	}
+	visibility, err := parseVisibility(input.Visibility)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	contentWarning, hold, err := parseContentWarning(cfg.textValidator(), input.ContentWarning)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	draft.Hold = draft.Hold || hold
+	publishAt := sql.NullTime{}
+	if input.PublishAt != nil {
+		if err := validatePublishAt(*input.PublishAt); err != nil {
+			return database.Chirp{}, err
+		}
+		publishAt = sql.NullTime{Time: input.PublishAt.UTC(), Valid: true}
+	}
+	// Polls and expiry count from when the chirp goes out.
+	opensAt := time.Now()
+	if publishAt.Valid {
+		opensAt = publishAt.Time
+	}
+	expiresAt, err := parseExpiry(input.ExpiresAt, input.TTLSeconds, opensAt)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	var pollOptions []string
+	if input.Poll != nil {
+		pollOptions, hold, err = validatePoll(cfg.textValidator(), *input.Poll, opensAt)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		draft.Hold = draft.Hold || hold
+	}
+
+	// A held chirp keeps its schedule, which applies once it's approved.
+	status := chirpStatusPublished
+	if draft.Hold {
+		status = chirpStatusHeld
+	} else if publishAt.Valid {
+		status = chirpStatusScheduled
+	}
+
+	parentChirpID := uuid.NullUUID{}
+	if input.InReplyTo != nil {
+		parent, err := getOriginalChirp(ctx, q, *input.InReplyTo, userID)
+		if err != nil {
+			return database.Chirp{}, fmt.Errorf("%w: %w", errReplyTargetNotFound, err)
+		}
+		parentChirpID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	quotedChirpID := uuid.NullUUID{}
+	if input.QuotedChirpID != nil {
+		quoted, err := getOriginalChirp(ctx, q, *input.QuotedChirpID, userID)
+		if err != nil {
+			return database.Chirp{}, fmt.Errorf("%w: %w", errQuoteTargetNotFound, err)
+		}
+		quotedChirpID = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
 
-	if len(body) > maxChirpLength {
-		return "", errors.New("Chirp is too long")
+	chirp, err := q.CreateChirp(ctx, database.CreateChirpParams{
+		Body:           draft.Body,
+		UserID:         userID,
+		ParentChirpID:  parentChirpID,
+		QuotedChirpID:  quotedChirpID,
+		Status:         status,
+		PublishAt:      publishAt,
+		Visibility:     visibility,
+		ContentWarning: contentWarning,
+		Sensitive:      input.Sensitive,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		return database.Chirp{}, fmt.Errorf("couldn't create chirp: %w", err)
+	}
+	if err := saveChirpHashtags(ctx, q, chirp); err != nil {
+		return database.Chirp{}, fmt.Errorf("couldn't save hashtags: %w", err)
+	}
+	if err := saveChirpMentions(ctx, q, chirp); err != nil {
+		return database.Chirp{}, fmt.Errorf("couldn't save mentions: %w", err)
+	}
+	if err := attachChirpMedia(ctx, q, chirp, input.Media); err != nil {
+		return database.Chirp{}, err
+	}
+	if input.Poll != nil {
+		if err := savePoll(ctx, q, chirp.ID, pollOptions, input.Poll.ClosesAt); err != nil {
+			return database.Chirp{}, fmt.Errorf("couldn't save poll: %w", err)
+		}
 	}
 
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+	if chirp.Status == chirpStatusPublished {
+		if err := countChirpReferences(ctx, q, chirp); err != nil {
+			return database.Chirp{}, fmt.Errorf("couldn't update chirp counts: %w", err)
+		}
 	}
 
-	cleanedBody := getCleanedBody(body, badWords)
-	return cleanedBody, nil
+	return chirp, nil
 }
 
-func getCleanedBody(body string, badWords map[string]struct{}) string {
-	words := strings.Split(body, " ")
-	for i, word := range words {
-		loweredWord := strings.ToLower(word)
-		if _, ok := badWords[loweredWord]; ok {
-			words[i] = "****"
+func respondWithCreateChirpError(w http.ResponseWriter, err error) {
+	var fieldErrs validation.FieldErrors
+	switch {
+	case errors.As(err, &fieldErrs):
+		respondWithValidationError(w, err)
+	case errors.Is(err, errAccountSuspended):
+		respondWithError(w, http.StatusForbidden, "Account is suspended", err)
+	case errors.Is(err, errReplyTargetNotFound):
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp to reply to", err)
+	case errors.Is(err, errQuoteTargetNotFound):
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp to quote", err)
+	case errors.Is(err, errMediaNotFound):
+		respondWithError(w, http.StatusBadRequest, "Couldn't find media to attach", err)
+	case isUniqueViolation(err):
+		respondWithError(w, http.StatusConflict, "Media is already attached to a chirp", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+	}
+}
+
+// countChirpReferences counts a newly published chirp as a reply to its
+// parent and a quote of the chirp it quotes.
+func countChirpReferences(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if chirp.ParentChirpID.Valid {
+		if err := q.IncrementChirpReplyCount(ctx, chirp.ParentChirpID.UUID); err != nil {
+			return err
+		}
+	}
+	if chirp.QuotedChirpID.Valid {
+		if err := q.IncrementChirpQuoteCount(ctx, chirp.QuotedChirpID.UUID); err != nil {
+			return err
 		}
 	}
-	cleaned := strings.Join(words, " ")
-	return cleaned
+	return nil
 }
 
+// getOriginalChirp loads a chirp that userID is about to reply to, quote,
+// rechirp or react to. A rechirp stands in for the chirp it reshares, so
+// that chirp is returned instead.
+func getOriginalChirp(ctx context.Context, q *database.Queries, chirpID, userID uuid.UUID) (database.Chirp, error) {
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+	chirp, err := q.GetChirpByID(ctx, database.GetChirpByIDParams{ID: chirpID, ViewerID: viewerID})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if chirp.RechirpOfID.Valid {
+		chirp, err = q.GetChirpByID(ctx, database.GetChirpByIDParams{ID: chirp.RechirpOfID.UUID, ViewerID: viewerID})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if chirp.TombstonedAt.Valid {
+		return database.Chirp{}, errors.New("chirp has been deleted")
+	}
+	if chirp.Status != chirpStatusPublished {
+		return database.Chirp{}, errors.New("chirp isn't published")
+	}
+	return chirp, nil
+}
+
+// parseVisibility checks the visibility asked for a chirp, which is public
+// when it's left out.
+func parseVisibility(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return chirpVisibilityPublic, nil
+	case chirpVisibilityPublic, chirpVisibilityFollowers, chirpVisibilityMentioned, chirpVisibilityPrivate:
+		return visibility, nil
+	}
+	return "", validation.FieldErrors{{
+		Field:   "visibility",
+		Code:    "invalid",
+		Message: "visibility must be public, followers, mentioned or private",
+	}}
+}
+
+// parseContentWarning cleans up the content warning asked for a chirp with
+// chain, like its body, and checks its length. hold is set if the warning
+// means the chirp has to be reviewed.
+func parseContentWarning(chain validation.ChirpChain, contentWarning string) (string, bool, error) {
+	contentWarning, hold, err := cleanText(chain, "content_warning", contentWarning)
+	if err != nil {
+		return "", false, err
+	}
+	if utf8.RuneCountInString(contentWarning) > maxContentWarningLength {
+		return "", false, validation.FieldErrors{{
+			Field:   "content_warning",
+			Code:    "too_long",
+			Message: fmt.Sprintf("content_warning can be at most %d characters", maxContentWarningLength),
+		}}
+	}
+	return contentWarning, hold, nil
+}
+
+// cleanText runs text through chain, reporting any problems against field.
+func cleanText(chain validation.ChirpChain, field, text string) (string, bool, error) {
+	draft := validation.ChirpDraft{Body: text}
+	if err := chain.Validate(&draft); err != nil {
+		var fieldErrs validation.FieldErrors
+		if !errors.As(err, &fieldErrs) {
+			return "", false, err
+		}
+		for i := range fieldErrs {
+			fieldErrs[i].Field = field
+		}
+		return "", false, fieldErrs
+	}
+	return draft.Body, draft.Hold, nil
+}
+
+// validateChirp runs a draft through the validation chain, with the length
+// limit of the author's plan. The draft comes back with its body cleaned up
+// and masked, and Hold set if it has to be reviewed. Problems with the draft
+// are returned as validation.FieldErrors.
+func (cfg *apiConfig) validateChirp(ctx context.Context, userID uuid.UUID, draft *validation.ChirpDraft) error {
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("couldn't get author: %w", err)
+	}
+	if user.SuspendedAt.Valid {
+		return errAccountSuspended
+	}
+	limits, err := cfg.entitlements.limits(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return cfg.chirpValidator(limits).Validate(draft)
+}
+
+// chirpValidator builds the validation chain for chirps under a plan's
+// limits.
+func (cfg *apiConfig) chirpValidator(limits planLimits) validation.ChirpChain {
+	return validation.ChirpChain{
+		validation.NormalizeBody,
+		validation.StripInvisible,
+		validation.TrimBody,
+		validation.RequireContent,
+		validation.MaxLength(limits.maxChirpLength),
+		validation.FilterProfanity(cfg.profanityFilter.Load()),
+	}
+}
+
+// textValidator cleans up the text that goes out with a chirp besides its
+// body, the way the body is cleaned up.
+func (cfg *apiConfig) textValidator() validation.ChirpChain {
+	return validation.ChirpChain{
+		validation.NormalizeBody,
+		validation.StripInvisible,
+		validation.TrimBody,
+		validation.FilterProfanity(cfg.profanityFilter.Load()),
+	}
+}
+
+// handlerGetChirps lists chirps as a bare array, as it always has. The
+// cursor for the next page goes in a Link header.
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.getViewerID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	mutedMode, ok := parseMutedMode(r)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "muted must be hide or collapse", nil)
+		return
+	}
+
 	authorIDString := r.URL.Query().Get("author_id")
+	pinnedFirst := r.URL.Query().Get("pinned_first") == "true"
+	if pinnedFirst && authorIDString == "" {
+		respondWithError(w, http.StatusBadRequest, "pinned_first needs an author_id", nil)
+		return
+	}
 	var dbChirps []database.Chirp
-	var err error
+	var pinnedChirps []database.Chirp
 
 	if authorIDString != "" {
 		authorID, err := uuid.Parse(authorIDString)
@@ -121,63 +456,119 @@ func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		dbChirps, err = cfg.db.GetChirpsByUserID(r.Context(), authorID)
-		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				// User exists but has no chirps
-				respondWithJSON(w, http.StatusOK, []Chirp{})
+		// Pinned chirps head the first page, and show up again in their
+		// usual place in the stream.
+		if pinnedFirst && page.cursor == nil {
+			pinnedChirps, err = cfg.db.GetPinnedChirps(r.Context(), database.GetPinnedChirpsParams{
+				UserID:   authorID,
+				ViewerID: viewerID,
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't get pinned chirps", err)
 				return
 			}
-			respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps", err)
-			return
+		}
+
+		if page.descending {
+			dbChirps, err = cfg.db.GetChirpsByUserIDDesc(r.Context(), database.GetChirpsByUserIDDescParams{
+				UserID:          authorID,
+				CursorCreatedAt: page.cursorCreatedAt(),
+				CursorID:        page.cursorID(),
+				Limit:           page.fetchLimit(),
+				ViewerID:        viewerID,
+			})
+		} else {
+			dbChirps, err = cfg.db.GetChirpsByUserID(r.Context(), database.GetChirpsByUserIDParams{
+				UserID:          authorID,
+				CursorCreatedAt: page.cursorCreatedAt(),
+				CursorID:        page.cursorID(),
+				Limit:           page.fetchLimit(),
+				ViewerID:        viewerID,
+			})
 		}
 	} else {
-		dbChirps, err = cfg.db.GetChirps(r.Context())
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps", err)
-			return
+		if page.descending {
+			dbChirps, err = cfg.db.GetChirpsDesc(r.Context(), database.GetChirpsDescParams{
+				CursorCreatedAt: page.cursorCreatedAt(),
+				CursorID:        page.cursorID(),
+				Limit:           page.fetchLimit(),
+				ViewerID:        viewerID,
+			})
+		} else {
+			dbChirps, err = cfg.db.GetChirps(r.Context(), database.GetChirpsParams{
+				CursorCreatedAt: page.cursorCreatedAt(),
+				CursorID:        page.cursorID(),
+				Limit:           page.fetchLimit(),
+				ViewerID:        viewerID,
+			})
 		}
 	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps", err)
+		return
+	}
 
-	IsDescending := false
-	sortDirectionParam := r.URL.Query().Get("sort")
-	if sortDirectionParam == "desc" {
-		IsDescending = true
+	dbChirps, nextCursor := nextPage(dbChirps, page.limit, chirpCursor)
+	chirps, err := cfg.populateChirps(r.Context(), viewerID, append(pinnedChirps, dbChirps...))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirps", err)
+		return
+	}
+	for i := range pinnedChirps {
+		chirps[i].Pinned = true
+	}
+	chirps, err = cfg.applyMutedWords(r.Context(), viewerID, chirps, mutedMode)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't apply muted words", err)
+		return
 	}
 
-	chirps := populateChirps(dbChirps, IsDescending)
+	setNextLink(w, r, nextCursor)
 	respondWithJSON(w, http.StatusOK, chirps)
 }
 
-func populateChirps(dbChirps []database.Chirp, IsDescending bool) []Chirp {
-	chirps := make([]Chirp, len(dbChirps))
+// chirpCursor marks a chirp's place in a timeline, which goes by when
+// chirps were published.
+func chirpCursor(dbChirp database.Chirp) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: dbChirp.PublishedAt, ID: dbChirp.ID}
+}
 
-	if IsDescending {
-		for i, dbChirp := range dbChirps {
-			chirps[(len(dbChirps)-1)-i] = Chirp{
-				ID:        dbChirp.ID,
-				CreatedAt: dbChirp.CreatedAt,
-				UpdatedAt: dbChirp.UpdatedAt,
-				Body:      dbChirp.Body,
-				UserID:    dbChirp.UserID,
-			}
-		}
-	} else {
-		for i, dbChirp := range dbChirps {
-			chirps[i] = Chirp{
-				ID:        dbChirp.ID,
-				CreatedAt: dbChirp.CreatedAt,
-				UpdatedAt: dbChirp.UpdatedAt,
-				Body:      dbChirp.Body,
-				UserID:    dbChirp.UserID,
-			}
-		}
+func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request) {
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
 	}
 
-	return chirps
+	viewerID, err := cfg.getViewerID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil || dbChirp.TombstonedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp by ID", err)
+		return
+	}
+
+	chirp, err := cfg.populateChirp(r.Context(), viewerID, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, chirp)
 }
 
-func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request) {
+func (cfg *apiConfig) handlerUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
 	chirpIDString := r.PathValue("chirpID")
 	chirpID, err := uuid.Parse(chirpIDString)
 	if err != nil {
@@ -185,19 +576,140 @@ func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	dbChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.TombstonedAt.Valid || chirp.DeletedAt.Valid {
 		respondWithError(w, http.StatusNotFound, "Couldn't get chirp by ID", err)
 		return
 	}
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Not authorized to edit this chirp", nil)
+		return
+	}
+	if chirp.RechirpOfID.Valid {
+		respondWithError(w, http.StatusBadRequest, "Rechirps can't be edited", nil)
+		return
+	}
+	if chirp.Status == chirpStatusHeld {
+		respondWithError(w, http.StatusForbidden, "Chirp is awaiting review", nil)
+		return
+	}
+	if chirp.Status == chirpStatusHidden {
+		respondWithError(w, http.StatusForbidden, "Chirp has been hidden by a moderator", nil)
+		return
+	}
+
+	attachments, err := qtx.GetChirpMedia(r.Context(), []uuid.UUID{chirp.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp media", err)
+		return
+	}
+	draft := validation.ChirpDraft{Body: params.Body, MediaCount: len(attachments)}
+	err = cfg.validateChirp(r.Context(), userID, &draft)
+	if errors.Is(err, errAccountSuspended) {
+		respondWithError(w, http.StatusForbidden, "Account is suspended", err)
+		return
+	}
+	if err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+	// An edit can't take back a chirp that others may already have seen,
+	// so words that would hold a new chirp reject the edit instead.
+	if draft.Hold {
+		respondWithValidationError(w, validation.FieldErrors{{
+			Field:   "body",
+			Code:    "needs_review",
+			Message: "Chirp contains a word that needs review",
+		}})
+		return
+	}
+	// A scheduled chirp hasn't been seen by anyone yet, so it can be
+	// edited freely until it's published.
+	published := chirp.Status == chirpStatusPublished
+	limits, err := cfg.entitlements.limits(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get plan limits", err)
+		return
+	}
+	// Database times are UTC; see withUTCTimeZone.
+	if published && time.Since(chirp.PublishedAt) > limits.chirpEditWindow {
+		respondWithError(w, http.StatusForbidden, "Chirp can no longer be edited", nil)
+		return
+	}
+
+	// Keep the body being replaced so readers can see what changed.
+	if published {
+		if _, err := qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+			ChirpID: chirp.ID,
+			Body:    chirp.Body,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't save chirp revision", err)
+			return
+		}
+	}
 
-	respondWithJSON(w, http.StatusOK, Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	updatedChirp, err := qtx.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+		ID:   chirp.ID,
+		Body: draft.Body,
 	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	if err := qtx.DeleteChirpHashtags(r.Context(), chirp.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update hashtags", err)
+		return
+	}
+	if err := saveChirpHashtags(r.Context(), qtx, updatedChirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update hashtags", err)
+		return
+	}
+	if err := qtx.DeleteChirpMentions(r.Context(), chirp.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update mentions", err)
+		return
+	}
+	if err := saveChirpMentions(r.Context(), qtx, updatedChirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update mentions", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	respChirp, err := cfg.populateChirp(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, updatedChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, respChirp)
 }
 
 func (cfg *apiConfig) handlerDeleteChirpByID(w http.ResponseWriter, r *http.Request) {
@@ -219,8 +731,16 @@ func (cfg *apiConfig) handlerDeleteChirpByID(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	chirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.TombstonedAt.Valid || chirp.DeletedAt.Valid {
 		respondWithError(w, http.StatusNotFound, "Couldn't get chirp by ID", err)
 		return
 	}
@@ -229,10 +749,55 @@ func (cfg *apiConfig) handlerDeleteChirpByID(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := cfg.db.DeleteChirpByID(r.Context(), chirp.ID); err != nil {
+	if err := trashChirp(r.Context(), qtx, chirp); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp by id", err)
 		return
 	}
 
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// trashChirp moves a chirp to its author's trash, where it can be restored
+// until purgeChirp removes it for good. While it's there a published chirp
+// no longer counts as a reply or a quote, and it's unpinned for good.
+// Rechirps hold nothing worth restoring, so they're removed straight away.
+func trashChirp(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if chirp.RechirpOfID.Valid {
+		if err := q.DeleteChirpByID(ctx, chirp.ID); err != nil {
+			return err
+		}
+		return q.DecrementChirpRechirpCount(ctx, chirp.RechirpOfID.UUID)
+	}
+
+	if err := q.TrashChirp(ctx, chirp.ID); err != nil {
+		return err
+	}
+	if err := q.DeleteChirpPins(ctx, chirp.ID); err != nil {
+		return err
+	}
+	if chirp.Status != chirpStatusPublished {
+		return nil
+	}
+	return uncountChirpReferences(ctx, q, chirp)
+}
+
+// uncountChirpReferences undoes countChirpReferences for a chirp that is
+// leaving public view.
+func uncountChirpReferences(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if chirp.ParentChirpID.Valid {
+		if err := q.DecrementChirpReplyCount(ctx, chirp.ParentChirpID.UUID); err != nil {
+			return err
+		}
+	}
+	if chirp.QuotedChirpID.Valid {
+		if err := q.DecrementChirpQuoteCount(ctx, chirp.QuotedChirpID.UUID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
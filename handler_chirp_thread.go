@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+)
+
+const (
+	// maxThreadAncestors bounds how far up a reply chain we walk looking for the root.
+	maxThreadAncestors = 100
+	// maxThreadDepth bounds how many levels of replies we return below a chirp.
+	maxThreadDepth = 10
+)
+
+type ThreadReply struct {
+	Chirp
+	Depth int32 `json:"depth"`
+}
+
+// handlerGetChirpThread returns the conversation around a chirp: the root,
+// the ancestors between the root and the chirp, and the replies below it
+// oldest first. Deleted chirps that still have replies appear as tombstones,
+// and ancestors the viewer can't read as unavailable placeholders.
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Root        Chirp         `json:"root"`
+		Ancestors   []Chirp       `json:"ancestors"`
+		Chirp       Chirp         `json:"chirp"`
+		Descendants []ThreadReply `json:"descendants"`
+		NextCursor  string        `json:"next_cursor,omitempty"`
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	viewerID, err := cfg.getViewerID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp by ID", err)
+		return
+	}
+
+	ancestorRows, err := cfg.db.GetChirpAncestors(r.Context(), database.GetChirpAncestorsParams{
+		ChirpID:  dbChirp.ID,
+		MaxDepth: maxThreadAncestors,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp ancestors", err)
+		return
+	}
+
+	dbAncestors := []database.Chirp{}
+	for _, row := range ancestorRows {
+		if row.Visible {
+			dbAncestors = append(dbAncestors, row.Chirp)
+		}
+	}
+
+	rows, err := cfg.db.GetChirpDescendants(r.Context(), database.GetChirpDescendantsParams{
+		ChirpID:         dbChirp.ID,
+		MaxDepth:        maxThreadDepth,
+		ViewerID:        viewerID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp replies", err)
+		return
+	}
+
+	rows, nextCursor := nextPage(rows, page.limit, func(row database.GetChirpDescendantsRow) pagination.Cursor {
+		return chirpCursor(row.Chirp)
+	})
+
+	// Populate the whole thread in one go: ancestors, chirp, replies.
+	dbChirps := append(dbAncestors, dbChirp)
+	for _, row := range rows {
+		dbChirps = append(dbChirps, row.Chirp)
+	}
+	chirps, err := cfg.populateChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirps", err)
+		return
+	}
+	chirp := chirps[len(dbAncestors)]
+	replies := chirps[len(dbAncestors)+1:]
+
+	// Ancestors the viewer can't read keep their place in the chain.
+	ancestors := make([]Chirp, len(ancestorRows))
+	visible := chirps[:len(dbAncestors)]
+	for i, row := range ancestorRows {
+		if row.Visible {
+			ancestors[i], visible = visible[0], visible[1:]
+		} else {
+			ancestors[i] = unavailableChirp(row.Chirp.ID)
+		}
+	}
+	root := chirp
+	if len(ancestors) > 0 {
+		root = ancestors[0]
+		ancestors = ancestors[1:]
+	}
+
+	descendants := make([]ThreadReply, len(rows))
+	for i, row := range rows {
+		descendants[i] = ThreadReply{
+			Chirp: replies[i],
+			Depth: row.Depth,
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Root:        root,
+		Ancestors:   ancestors,
+		Chirp:       chirp,
+		Descendants: descendants,
+		NextCursor:  nextCursor,
+	})
+}
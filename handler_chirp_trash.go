@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+)
+
+const purgeBatchSize = 100
+
+// handlerGetTrash lists the user's trashed chirps, most recently deleted first.
+func (cfg *apiConfig) handlerGetTrash(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetTrashedChirpsByUserID(r.Context(), database.GetTrashedChirpsByUserIDParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get trashed chirps", err)
+		return
+	}
+
+	dbChirps, nextCursor := nextPage(dbChirps, page.limit, func(dbChirp database.Chirp) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: dbChirp.DeletedAt.Time, ID: dbChirp.ID}
+	})
+	chirps, err := cfg.populateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerRestoreChirp takes a chirp back out of the trash, as it was before.
+// What it referred to may have changed in the meantime. A reply can't come
+// back once the author can no longer see the chirp it replied to, but a
+// quote just loses the chirp it quoted, as it would if that were purged.
+func (cfg *apiConfig) handlerRestoreChirp(w http.ResponseWriter, r *http.Request) {
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.UserID != userID || !chirp.DeletedAt.Valid || chirp.TombstonedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp in trash", err)
+		return
+	}
+
+	if chirp.ParentChirpID.Valid {
+		if _, err := getOriginalChirp(r.Context(), qtx, chirp.ParentChirpID.UUID, userID); err != nil {
+			respondWithError(w, http.StatusConflict, "The chirp this replies to is no longer available", err)
+			return
+		}
+	}
+	if chirp.QuotedChirpID.Valid {
+		if _, err := getOriginalChirp(r.Context(), qtx, chirp.QuotedChirpID.UUID, userID); err != nil {
+			if err := qtx.DetachQuotedChirp(r.Context(), chirp.ID); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't restore chirp", err)
+				return
+			}
+		}
+	}
+
+	restored, err := qtx.RestoreChirp(r.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't restore chirp", err)
+		return
+	}
+	if restored.Status == chirpStatusPublished {
+		if err := countChirpReferences(r.Context(), qtx, restored); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp counts", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	respChirp, err := cfg.populateChirp(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, restored)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, respChirp)
+}
+
+// purgeChirp removes a trashed chirp for good, unless other chirps reply to
+// it, trashed replies included. Then its content, rechirps, reactions,
+// hashtags, mentions and attachments are wiped but the row stays behind as
+// a tombstone so the thread around it keeps its shape. A tombstone goes too
+// once its last reply is removed, and so on up the thread. Either way the
+// chirp's media is deleted, and the keys of the files it leaves behind are
+// returned for the caller to delete once the transaction commits.
+func purgeChirp(ctx context.Context, q *database.Queries, chirp database.Chirp) ([]string, error) {
+	media, err := q.DeleteChirpMedia(ctx, chirp.ID)
+	if err != nil {
+		return nil, err
+	}
+	blobKeys := make([]string, 0, 2*len(media))
+	for _, m := range media {
+		blobKeys = append(blobKeys, m.StorageKey, m.ThumbnailKey)
+	}
+
+	hasReplies, err := q.ChirpHasReplies(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	if !hasReplies {
+		if err := q.DeleteChirpByID(ctx, chirp.ID); err != nil {
+			return nil, err
+		}
+		return blobKeys, deleteUnrepliedTombstones(ctx, q, chirp.ParentChirpID)
+	}
+
+	if err := q.DeleteChirpRevisions(ctx, chirp.ID); err != nil {
+		return nil, err
+	}
+	if err := q.DeleteChirpReactions(ctx, chirp.ID); err != nil {
+		return nil, err
+	}
+	if err := q.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+		return nil, err
+	}
+	if err := q.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return nil, err
+	}
+	if err := q.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirp.ID, Valid: true}); err != nil {
+		return nil, err
+	}
+	return blobKeys, q.TombstoneChirp(ctx, chirp.ID)
+}
+
+// deleteUnrepliedTombstones deletes parentID if it's a tombstone with no
+// replies left, then its parent on the same terms, until it reaches a chirp
+// that has to stay.
+func deleteUnrepliedTombstones(ctx context.Context, q *database.Queries, parentID uuid.NullUUID) error {
+	for parentID.Valid {
+		var err error
+		parentID, err = q.DeleteUnrepliedTombstone(ctx, parentID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteChirpNow removes a chirp for good without a stay in the trash,
+// as if it had been trashed and then purged. Like purgeChirp, it returns
+// the keys of the files left to delete.
+func deleteChirpNow(ctx context.Context, q *database.Queries, chirp database.Chirp) ([]string, error) {
+	if !chirp.DeletedAt.Valid {
+		if err := trashChirp(ctx, q, chirp); err != nil {
+			return nil, err
+		}
+		// Rechirps are deleted outright rather than trashed.
+		if chirp.RechirpOfID.Valid {
+			return nil, nil
+		}
+	}
+	return purgeChirp(ctx, q, chirp)
+}
+
+// purgeTrashedChirps purges chirps that have been in the trash longer than
+// the retention period, in batches. Rows another instance is already
+// purging are skipped rather than waited for.
+func (cfg *apiConfig) purgeTrashedChirps(ctx context.Context) error {
+	for {
+		purged, err := cfg.purgeTrashedChirpBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if purged < purgeBatchSize {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) purgeTrashedChirpBatch(ctx context.Context) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirps, err := qtx.GetChirpsToPurge(ctx, database.GetChirpsToPurgeParams{
+		DeletedAt: sql.NullTime{Time: time.Now().UTC().Add(-cfg.chirpTrashRetention), Valid: true},
+		Limit:     purgeBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var blobKeys []string
+	for _, chirp := range chirps {
+		keys, err := purgeChirp(ctx, qtx, chirp)
+		if err != nil {
+			return 0, err
+		}
+		blobKeys = append(blobKeys, keys...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	cfg.deleteBlobs(blobKeys...)
+	if len(chirps) > 0 {
+		log.Printf("Purged %d trashed chirps", len(chirps))
+	}
+	return len(chirps), nil
+}
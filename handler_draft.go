@@ -0,0 +1,658 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+	"github.com/katsuikeda/chirpy/internal/validation"
+)
+
+// maxDraftBodyBytes bounds what we store for a draft. Drafts may run over
+// the chirp length limit, but not without limit.
+const maxDraftBodyBytes = 10000
+
+type Draft struct {
+	ID             uuid.UUID        `json:"id"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	Body           string           `json:"body"`
+	InReplyTo      *uuid.UUID       `json:"in_reply_to"`
+	QuotedChirpID  *uuid.UUID       `json:"quoted_chirp_id"`
+	Media          []mediaParameter `json:"media"`
+	PublishAt      *time.Time       `json:"publish_at"`
+	Visibility     string           `json:"visibility"`
+	ContentWarning string           `json:"content_warning"`
+	Sensitive      bool             `json:"sensitive"`
+	// Version goes up with every change. Updates must send the version
+	// they're based on, so one device can't overwrite another's edit.
+	Version int32 `json:"version"`
+	Deleted bool  `json:"deleted"`
+	// Problems lists what would stop the draft from being published as it
+	// stands.
+	Problems validation.FieldErrors `json:"problems,omitempty"`
+}
+
+type draftParameters struct {
+	chirpInput
+	Version int32 `json:"version"`
+}
+
+// checkDraftSize rejects drafts too big to store, and polls and expiry,
+// which drafts don't keep. Anything else wrong with a draft is reported by
+// draftProblems instead.
+func checkDraftSize(input chirpInput) error {
+	fieldErrs := validation.FieldErrors{}
+	if len(input.Body) > maxDraftBodyBytes {
+		fieldErrs = append(fieldErrs, validation.FieldError{
+			Field:   "body",
+			Code:    "too_large",
+			Message: fmt.Sprintf("Draft can be at most %d bytes", maxDraftBodyBytes),
+		})
+	}
+	if len(input.Media) > maxChirpMedia {
+		fieldErrs = append(fieldErrs, validation.FieldError{
+			Field:   "media",
+			Code:    "too_many",
+			Message: fmt.Sprintf("A chirp can have at most %d attachments", maxChirpMedia),
+		})
+	}
+	if input.Poll != nil {
+		fieldErrs = append(fieldErrs, validation.FieldError{
+			Field:   "poll",
+			Code:    "invalid",
+			Message: "Drafts can't have a poll",
+		})
+	}
+	if input.ExpiresAt != nil || input.TTLSeconds != nil {
+		fieldErrs = append(fieldErrs, validation.FieldError{
+			Field:   "expires_at",
+			Code:    "invalid",
+			Message: "Drafts can't have an expiry",
+		})
+	}
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+	return nil
+}
+
+// draftProblems runs a draft through the same checks as a new chirp,
+// collecting the problems rather than failing on them. It doesn't look up
+// reply and quote targets or attachments; publishing does.
+func draftProblems(chain validation.ChirpChain, input chirpInput) (validation.FieldErrors, error) {
+	problems := validation.FieldErrors{}
+	collect := func(err error) error {
+		if err == nil {
+			return nil
+		}
+		var fieldErrs validation.FieldErrors
+		if !errors.As(err, &fieldErrs) {
+			return err
+		}
+		problems = append(problems, fieldErrs...)
+		return nil
+	}
+
+	if err := collect(validateMediaParameters(input.Media)); err != nil {
+		return nil, err
+	}
+	draft := validation.ChirpDraft{Body: input.Body, MediaCount: len(input.Media)}
+	if err := collect(chain.Validate(&draft)); err != nil {
+		return nil, err
+	}
+	if input.PublishAt != nil {
+		if err := collect(validatePublishAt(*input.PublishAt)); err != nil {
+			return nil, err
+		}
+	}
+	return problems, nil
+}
+
+func draftInput(dbDraft database.Draft) (chirpInput, error) {
+	input := chirpInput{
+		Body:           dbDraft.Body,
+		Visibility:     dbDraft.Visibility,
+		ContentWarning: dbDraft.ContentWarning,
+		Sensitive:      dbDraft.Sensitive,
+	}
+	if err := json.Unmarshal(dbDraft.Media, &input.Media); err != nil {
+		return chirpInput{}, fmt.Errorf("couldn't decode draft media: %w", err)
+	}
+	if dbDraft.InReplyTo.Valid {
+		input.InReplyTo = &dbDraft.InReplyTo.UUID
+	}
+	if dbDraft.QuotedChirpID.Valid {
+		input.QuotedChirpID = &dbDraft.QuotedChirpID.UUID
+	}
+	if dbDraft.PublishAt.Valid {
+		input.PublishAt = &dbDraft.PublishAt.Time
+	}
+	return input, nil
+}
+
+// newDraft builds the response for a draft, checked against chain. Deleted
+// drafts are tombstones for clients to sync, and have nothing to check.
+func newDraft(dbDraft database.Draft, chain validation.ChirpChain) (Draft, error) {
+	input, err := draftInput(dbDraft)
+	if err != nil {
+		return Draft{}, err
+	}
+
+	draft := Draft{
+		ID:             dbDraft.ID,
+		CreatedAt:      dbDraft.CreatedAt,
+		UpdatedAt:      dbDraft.UpdatedAt,
+		Body:           input.Body,
+		InReplyTo:      input.InReplyTo,
+		QuotedChirpID:  input.QuotedChirpID,
+		Media:          input.Media,
+		PublishAt:      input.PublishAt,
+		Visibility:     input.Visibility,
+		ContentWarning: input.ContentWarning,
+		Sensitive:      input.Sensitive,
+		Version:        dbDraft.Version,
+		Deleted:        dbDraft.DeletedAt.Valid,
+	}
+	if draft.Media == nil {
+		draft.Media = []mediaParameter{}
+	}
+	if !draft.Deleted {
+		draft.Problems, err = draftProblems(chain, input)
+		if err != nil {
+			return Draft{}, err
+		}
+	}
+	return draft, nil
+}
+
+// loadDraft builds the response for a single draft by userID.
+func (cfg *apiConfig) loadDraft(ctx context.Context, userID uuid.UUID, dbDraft database.Draft) (Draft, error) {
+	limits, err := cfg.entitlements.limits(ctx, userID)
+	if err != nil {
+		return Draft{}, err
+	}
+	return newDraft(dbDraft, cfg.chirpValidator(limits))
+}
+
+func draftMedia(input chirpInput) (json.RawMessage, error) {
+	media := input.Media
+	if media == nil {
+		media = []mediaParameter{}
+	}
+	return json.Marshal(media)
+}
+
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func (cfg *apiConfig) handlerCreateDraft(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := chirpInput{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	if err := checkDraftSize(params); err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+	contentWarning, _, err := parseContentWarning(cfg.textValidator(), params.ContentWarning)
+	if err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+	media, err := draftMedia(params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't encode draft media", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if _, err := qtx.GetUserByIDForUpdate(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	dbDraft, err := qtx.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:         userID,
+		Body:           params.Body,
+		InReplyTo:      nullUUID(params.InReplyTo),
+		QuotedChirpID:  nullUUID(params.QuotedChirpID),
+		Media:          media,
+		PublishAt:      nullTime(params.PublishAt),
+		Visibility:     visibility,
+		ContentWarning: contentWarning,
+		Sensitive:      params.Sensitive,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create draft", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	draft, err := cfg.loadDraft(r.Context(), userID, dbDraft)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load draft", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, draft)
+}
+
+// handlerGetDrafts lists the user's drafts in the order they last changed,
+// so a client can sync by keeping the last cursor it saw and asking for what
+// changed since. Pages after the first include deleted drafts as tombstones.
+// Tombstones are purged after a while, so a client that hasn't caught up
+// since then gets 410 and has to start again without a cursor.
+//
+// Every draft change takes the next value of a counter, with the user locked
+// so that one user's changes commit in counter order. That way nothing can
+// commit behind a cursor a client already has.
+func (cfg *apiConfig) handlerGetDrafts(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Drafts     []Draft `json:"drafts"`
+		NextCursor string  `json:"next_cursor"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := pagination.ParseLimit(query.Get("limit"), defaultPageLimit, maxPageLimit)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	var cursor *pagination.SyncCursor
+	if cursorString := query.Get("cursor"); cursorString != "" {
+		c, err := pagination.DecodeSyncCursor(cursorString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+			return
+		}
+		cursor = &c
+	}
+	if cursor != nil && time.Since(cursor.Since) > cfg.draftTombstoneRetention {
+		respondWithError(w, http.StatusGone, "Cursor has expired, sync again from the start", nil)
+		return
+	}
+	syncedAt := time.Now().UTC()
+
+	limits, err := cfg.entitlements.limits(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get plan limits", err)
+		return
+	}
+
+	next := pagination.SyncCursor{Since: syncedAt}
+	if cursor != nil {
+		next = *cursor
+	}
+	dbDrafts, err := cfg.db.GetDraftsByUserID(r.Context(), database.GetDraftsByUserIDParams{
+		UserID:         userID,
+		IncludeDeleted: cursor != nil,
+		AfterSeq:       next.Seq,
+		Limit:          int32(limit) + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get drafts", err)
+		return
+	}
+
+	// Since only moves up once the client has caught up. Until then, older
+	// tombstones it hasn't reached yet could be purged behind it.
+	if len(dbDrafts) > limit {
+		dbDrafts = dbDrafts[:limit]
+	} else {
+		next.Since = syncedAt
+	}
+	if len(dbDrafts) > 0 {
+		next.Seq = dbDrafts[len(dbDrafts)-1].ChangeSeq
+	}
+
+	chain := cfg.chirpValidator(limits)
+	drafts := make([]Draft, len(dbDrafts))
+	for i, dbDraft := range dbDrafts {
+		drafts[i], err = newDraft(dbDraft, chain)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't load draft", err)
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Drafts:     drafts,
+		NextCursor: next.Encode(),
+	})
+}
+
+func (cfg *apiConfig) handlerGetDraft(w http.ResponseWriter, r *http.Request) {
+	draftIDString := r.PathValue("draftID")
+	draftID, err := uuid.Parse(draftIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	dbDraft, err := cfg.db.GetDraftByID(r.Context(), draftID)
+	if err != nil || dbDraft.UserID != userID || dbDraft.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", err)
+		return
+	}
+
+	draft, err := cfg.loadDraft(r.Context(), userID, dbDraft)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load draft", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, draft)
+}
+
+// handlerUpdateDraft replaces a draft. If the draft has changed since the
+// version the client sent, it responds 409 with the current draft so the
+// client can merge and try again.
+func (cfg *apiConfig) handlerUpdateDraft(w http.ResponseWriter, r *http.Request) {
+	type conflictResponse struct {
+		Error string `json:"error"`
+		Draft Draft  `json:"draft"`
+	}
+
+	draftIDString := r.PathValue("draftID")
+	draftID, err := uuid.Parse(draftIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := draftParameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	if err := checkDraftSize(params.chirpInput); err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+	visibility, err := parseVisibility(params.Visibility)
+	if err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+	contentWarning, _, err := parseContentWarning(cfg.textValidator(), params.ContentWarning)
+	if err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+	media, err := draftMedia(params.chirpInput)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't encode draft media", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if _, err := qtx.GetUserByIDForUpdate(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	dbDraft, err := qtx.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		Body:           params.Body,
+		InReplyTo:      nullUUID(params.InReplyTo),
+		QuotedChirpID:  nullUUID(params.QuotedChirpID),
+		Media:          media,
+		PublishAt:      nullTime(params.PublishAt),
+		Visibility:     visibility,
+		ContentWarning: contentWarning,
+		Sensitive:      params.Sensitive,
+		ID:             draftID,
+		UserID:         userID,
+		Version:        params.Version,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		current, err := qtx.GetDraftByID(r.Context(), draftID)
+		if err != nil || current.UserID != userID || current.DeletedAt.Valid {
+			respondWithError(w, http.StatusNotFound, "Couldn't find draft", err)
+			return
+		}
+		draft, err := cfg.loadDraft(r.Context(), userID, current)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't load draft", err)
+			return
+		}
+		respondWithJSON(w, http.StatusConflict, conflictResponse{
+			Error: "Draft has changed since this version",
+			Draft: draft,
+		})
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update draft", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	draft, err := cfg.loadDraft(r.Context(), userID, dbDraft)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load draft", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, draft)
+}
+
+func (cfg *apiConfig) handlerDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	draftIDString := r.PathValue("draftID")
+	draftID, err := uuid.Parse(draftIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if _, err := qtx.GetUserByIDForUpdate(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	dbDraft, err := qtx.GetDraftByID(r.Context(), draftID)
+	if err != nil || dbDraft.UserID != userID || dbDraft.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", err)
+		return
+	}
+
+	if _, err := qtx.DeleteDraft(r.Context(), dbDraft.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete draft", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerPublishDraft turns a draft into a chirp, checked like any new
+// chirp, and deletes the draft in the same transaction.
+func (cfg *apiConfig) handlerPublishDraft(w http.ResponseWriter, r *http.Request) {
+	draftIDString := r.PathValue("draftID")
+	draftID, err := uuid.Parse(draftIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid draft ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if _, err := qtx.GetUserByIDForUpdate(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	dbDraft, err := qtx.GetDraftByIDForUpdate(r.Context(), draftID)
+	if err != nil || dbDraft.UserID != userID || dbDraft.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't find draft", err)
+		return
+	}
+	input, err := draftInput(dbDraft)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load draft", err)
+		return
+	}
+
+	chirp, err := cfg.createChirp(r.Context(), qtx, userID, input)
+	if err != nil {
+		respondWithCreateChirpError(w, err)
+		return
+	}
+
+	if _, err := qtx.DeleteDraft(r.Context(), dbDraft.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete draft", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	respChirp, err := cfg.populateChirp(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, respChirp)
+}
+
+// purgeDeletedDrafts deletes draft tombstones older than the retention
+// period. Sync cursors from before then are refused, so no client misses one.
+func (cfg *apiConfig) purgeDeletedDrafts(ctx context.Context) error {
+	purged, err := cfg.db.PurgeDeletedDrafts(ctx, sql.NullTime{
+		Time:  time.Now().UTC().Add(-cfg.draftTombstoneRetention),
+		Valid: true,
+	})
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("Purged %d deleted drafts", purged)
+	}
+	return nil
+}
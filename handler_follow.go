@@ -0,0 +1,300 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+)
+
+// FollowListEntry is one account in a follower or following list.
+type FollowListEntry struct {
+	ID         uuid.UUID `json:"id"`
+	Handle     string    `json:"handle,omitempty"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeIDString := r.PathValue("userID")
+	followeeID, err := uuid.Parse(followeeIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't follow yourself", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	userExists, err := qtx.UserExists(r.Context(), followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check if user exists", err)
+		return
+	}
+	if !userExists {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	blocked, err := qtx.BlockExistsBetween(r.Context(), database.BlockExistsBetweenParams{
+		BlockerID: followeeID,
+		BlockedID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "You can't follow this user", nil)
+		return
+	}
+
+	added, err := qtx.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't follow user", err)
+		return
+	}
+	// Following twice is a no-op, so only count the first time.
+	if added > 0 {
+		if err := qtx.IncrementFollowCounts(r.Context(), database.IncrementFollowCountsParams{
+			FollowerID: userID,
+			FolloweeID: followeeID,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update follow counts", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeIDString := r.PathValue("userID")
+	followeeID, err := uuid.Parse(followeeIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	removed, err := qtx.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unfollow user", err)
+		return
+	}
+	if removed > 0 {
+		if err := qtx.DecrementFollowCounts(r.Context(), database.DecrementFollowCountsParams{
+			FollowerID: userID,
+			FolloweeID: followeeID,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update follow counts", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetFollowers lists who follows a user, most recent first, along
+// with how many followers they have in all.
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.respondWithFollowList(w, r, func(user database.User, page pageParams) ([]FollowListEntry, int32, error) {
+		rows, err := cfg.db.GetFollowers(r.Context(), database.GetFollowersParams{
+			UserID:          user.ID,
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			Limit:           page.fetchLimit(),
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		entries := make([]FollowListEntry, len(rows))
+		for i, row := range rows {
+			entries[i] = FollowListEntry{ID: row.ID, Handle: row.Handle.String, FollowedAt: row.CreatedAt}
+		}
+		return entries, user.FollowerCount, nil
+	})
+}
+
+// handlerGetFollowing lists who a user follows, most recent first, along
+// with how many accounts they follow in all.
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.respondWithFollowList(w, r, func(user database.User, page pageParams) ([]FollowListEntry, int32, error) {
+		rows, err := cfg.db.GetFollowing(r.Context(), database.GetFollowingParams{
+			UserID:          user.ID,
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			Limit:           page.fetchLimit(),
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		entries := make([]FollowListEntry, len(rows))
+		for i, row := range rows {
+			entries[i] = FollowListEntry{ID: row.ID, Handle: row.Handle.String, FollowedAt: row.CreatedAt}
+		}
+		return entries, user.FollowingCount, nil
+	})
+}
+
+// respondWithFollowList does the work shared by the follower and following
+// lists; list loads one page of entries and the total count for the user.
+func (cfg *apiConfig) respondWithFollowList(w http.ResponseWriter, r *http.Request, list func(database.User, pageParams) ([]FollowListEntry, int32, error)) {
+	type response struct {
+		Users      []FollowListEntry `json:"users"`
+		Count      int32             `json:"count"`
+		NextCursor string            `json:"next_cursor,omitempty"`
+	}
+
+	userIDString := r.PathValue("userID")
+	userID, err := uuid.Parse(userIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	entries, count, err := list(user, page)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get follows", err)
+		return
+	}
+
+	entries, nextCursor := nextPage(entries, page.limit, func(entry FollowListEntry) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: entry.FollowedAt, ID: entry.ID}
+	})
+
+	respondWithJSON(w, http.StatusOK, response{
+		Users:      entries,
+		Count:      count,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerGetHomeTimeline lists the newest chirps from the accounts the user
+// follows and the user's own, newest first.
+func (cfg *apiConfig) handlerGetHomeTimeline(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	mutedMode, ok := parseMutedMode(r)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "muted must be hide or collapse", nil)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetHomeTimeline(r.Context(), database.GetHomeTimelineParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get home timeline", err)
+		return
+	}
+
+	dbChirps, nextCursor := nextPage(dbChirps, page.limit, chirpCursor)
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+	chirps, err := cfg.populateChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirps", err)
+		return
+	}
+	chirps, err = cfg.applyMutedWords(r.Context(), viewerID, chirps, mutedMode)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't apply muted words", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
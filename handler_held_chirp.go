@@ -0,0 +1,165 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+)
+
+// handlerGetHeldChirps lists the chirps waiting for review, oldest first.
+func (cfg *apiConfig) handlerGetHeldChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	adminID, ok := cfg.authenticateAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetHeldChirps(r.Context(), database.GetHeldChirpsParams{
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get held chirps", err)
+		return
+	}
+
+	// The queue goes by when chirps were written, as none are out yet.
+	dbChirps, nextCursor := nextPage(dbChirps, page.limit, func(dbChirp database.Chirp) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: dbChirp.CreatedAt, ID: dbChirp.ID}
+	})
+	chirps, err := cfg.populateChirps(r.Context(), uuid.NullUUID{UUID: adminID, Valid: true}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerApproveHeldChirp publishes a held chirp, or schedules it if it was
+// created for a time still to come. Once published it counts as a reply or
+// quote like any other chirp. A chirp whose poll would close too soon after
+// going out can't be approved.
+func (cfg *apiConfig) handlerApproveHeldChirp(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := cfg.authenticateAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.PublishHeldChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find held chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't publish chirp", err)
+		return
+	}
+	canOpen, err := pollCanOpen(r.Context(), qtx, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get poll", err)
+		return
+	}
+	if !canOpen {
+		respondWithError(w, http.StatusConflict, "The chirp's poll would close too soon, reject it instead", nil)
+		return
+	}
+	if chirp.Status == chirpStatusPublished {
+		if err := qtx.UpdateChirpHashtagsCreatedAt(r.Context(), database.UpdateChirpHashtagsCreatedAtParams{
+			ChirpID:   chirp.ID,
+			CreatedAt: chirp.PublishedAt,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update hashtags", err)
+			return
+		}
+		if err := countChirpReferences(r.Context(), qtx, chirp); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update chirp counts", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	respChirp, err := cfg.populateChirp(r.Context(), uuid.NullUUID{UUID: adminID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, respChirp)
+}
+
+// handlerRejectHeldChirp moves a held chirp to its author's trash, which
+// keeps it around for a while as a record of the decision.
+func (cfg *apiConfig) handlerRejectHeldChirp(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.authenticateAdmin(w, r); !ok {
+		return
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.Status != chirpStatusHeld || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't find held chirp", err)
+		return
+	}
+	if err := trashChirp(r.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/blobstore"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/media"
+	"github.com/katsuikeda/chirpy/internal/validation"
+	"github.com/rivo/uniseg"
+)
+
+const (
+	maxChirpMedia    = 4
+	maxAltTextLength = 1000
+	// multipartOverhead leaves room for the multipart framing around the file.
+	multipartOverhead = 1 << 20
+)
+
+var errMediaNotFound = errors.New("couldn't find media")
+
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+type MediaAttachment struct {
+	Media
+	AltText string `json:"alt_text"`
+}
+
+type mediaParameter struct {
+	ID      uuid.UUID `json:"id"`
+	AltText string    `json:"alt_text"`
+}
+
+func newMedia(dbMedia database.Medium) Media {
+	return Media{
+		ID:           dbMedia.ID,
+		CreatedAt:    dbMedia.CreatedAt,
+		ContentType:  dbMedia.ContentType,
+		SizeBytes:    dbMedia.SizeBytes,
+		Width:        dbMedia.Width,
+		Height:       dbMedia.Height,
+		URL:          fmt.Sprintf("/api/media/%s", dbMedia.ID),
+		ThumbnailURL: fmt.Sprintf("/api/media/%s/thumbnail", dbMedia.ID),
+	}
+}
+
+func (cfg *apiConfig) handlerUploadMedia(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	limits, err := cfg.entitlements.limits(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get plan limits", err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, limits.maxUploadBytes+multipartOverhead)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large", err)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Couldn't find file in form", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limits.maxUploadBytes+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read file", err)
+		return
+	}
+	if int64(len(data)) > limits.maxUploadBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large", nil)
+		return
+	}
+
+	img, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only JPEG, PNG and GIF images are supported", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't process image", err)
+		return
+	}
+
+	mediaID := uuid.New()
+	storageKey := "media/" + mediaID.String()
+	thumbnailKey := storageKey + "_thumbnail"
+	if err := cfg.blobStore.Put(r.Context(), storageKey, bytes.NewReader(img.Data)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store file", err)
+		return
+	}
+	if err := cfg.blobStore.Put(r.Context(), thumbnailKey, bytes.NewReader(img.Thumbnail)); err != nil {
+		cfg.deleteBlobs(storageKey)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store thumbnail", err)
+		return
+	}
+
+	dbMedia, err := cfg.db.CreateMedia(r.Context(), database.CreateMediaParams{
+		ID:                   mediaID,
+		UserID:               userID,
+		ContentType:          img.ContentType,
+		SizeBytes:            int64(len(img.Data)),
+		Width:                int32(img.Width),
+		Height:               int32(img.Height),
+		StorageKey:           storageKey,
+		ThumbnailKey:         thumbnailKey,
+		ThumbnailContentType: img.ThumbnailContentType,
+	})
+	if err != nil {
+		cfg.deleteBlobs(storageKey, thumbnailKey)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save media", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newMedia(dbMedia))
+}
+
+// deleteBlobs removes files that nothing refers to anymore, such as an
+// upload that couldn't be saved or the media of a purged chirp. It runs
+// detached from the request, which may already have been cancelled.
+func (cfg *apiConfig) deleteBlobs(keys ...string) {
+	for _, key := range keys {
+		if err := cfg.blobStore.Delete(context.Background(), key); err != nil {
+			log.Printf("Error deleting blob %s: %v", key, err)
+		}
+	}
+}
+
+func (cfg *apiConfig) handlerGetMedia(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, false)
+}
+
+func (cfg *apiConfig) handlerGetMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, true)
+}
+
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	mediaIDString := r.PathValue("mediaID")
+	mediaID, err := uuid.Parse(mediaIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid media ID", err)
+		return
+	}
+
+	viewerID, err := cfg.getViewerID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	// Media someone isn't allowed to see is hidden the same way as media
+	// that doesn't exist.
+	row, err := cfg.db.GetMediaForViewer(r.Context(), database.GetMediaForViewerParams{
+		ID:       mediaID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't get media by ID", err)
+		return
+	}
+	dbMedia := row.Medium
+
+	key, contentType := dbMedia.StorageKey, dbMedia.ContentType
+	if thumbnail {
+		key, contentType = dbMedia.ThumbnailKey, dbMedia.ThumbnailContentType
+	}
+
+	blob, err := cfg.blobStore.Get(r.Context(), key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find media file", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't open media file", err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	if !thumbnail {
+		w.Header().Set("Content-Length", strconv.FormatInt(dbMedia.SizeBytes, 10))
+	}
+	// Stored files never change, but who may see them can, so only media
+	// on public chirps is left to shared caches, and not for long. The
+	// browser must not second-guess the type we sniffed on upload.
+	if row.Public {
+		w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Printf("Error serving media %s: %v", mediaID, err)
+	}
+}
+
+// attachChirpMedia links uploaded media to a new chirp, in the order given.
+// Only the chirp's author can attach their own uploads, and each upload
+// can be attached once.
+func attachChirpMedia(ctx context.Context, q *database.Queries, chirp database.Chirp, params []mediaParameter) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(params))
+	for i, param := range params {
+		ids[i] = param.ID
+	}
+	dbMedia, err := q.GetMediaByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	owned := map[uuid.UUID]bool{}
+	for _, m := range dbMedia {
+		owned[m.ID] = m.UserID == chirp.UserID
+	}
+
+	attach := database.CreateChirpMediaParams{ChirpID: chirp.ID}
+	for i, param := range params {
+		if !owned[param.ID] {
+			return fmt.Errorf("%w: %s", errMediaNotFound, param.ID)
+		}
+		attach.MediaIDs = append(attach.MediaIDs, param.ID)
+		attach.Positions = append(attach.Positions, int32(i))
+		attach.AltTexts = append(attach.AltTexts, param.AltText)
+	}
+
+	return q.CreateChirpMedia(ctx, attach)
+}
+
+// validateMediaParameters checks the attachments of a new chirp. Alt text
+// is limited in characters as users see them, like the chirp body.
+func validateMediaParameters(params []mediaParameter) error {
+	fieldErrs := validation.FieldErrors{}
+	if len(params) > maxChirpMedia {
+		fieldErrs = append(fieldErrs, validation.FieldError{
+			Field:   "media",
+			Code:    "too_many",
+			Message: fmt.Sprintf("A chirp can have at most %d attachments", maxChirpMedia),
+		})
+	}
+
+	seen := map[uuid.UUID]struct{}{}
+	for i, param := range params {
+		if _, ok := seen[param.ID]; ok {
+			fieldErrs = append(fieldErrs, validation.FieldError{
+				Field:   fmt.Sprintf("media[%d].id", i),
+				Code:    "duplicate",
+				Message: "Media can only be attached once",
+			})
+		}
+		seen[param.ID] = struct{}{}
+
+		if uniseg.GraphemeClusterCount(param.AltText) > maxAltTextLength {
+			fieldErrs = append(fieldErrs, validation.FieldError{
+				Field:   fmt.Sprintf("media[%d].alt_text", i),
+				Code:    "too_long",
+				Message: fmt.Sprintf("Alt text can be at most %d characters", maxAltTextLength),
+			})
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+	return nil
+}
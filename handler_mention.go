@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/chirptext"
+	"github.com/katsuikeda/chirpy/internal/database"
+)
+
+type Mention struct {
+	UserID uuid.UUID `json:"user_id"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+// saveChirpMentions resolves the @handle and @email mentions in a chirp's
+// body to users and stores them with their offsets. Mentions of unknown
+// users are left as plain text. So are @email mentions of users who can't
+// read the chirp: resolving them would tell the author whose address it is.
+func saveChirpMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	mentions := chirptext.Mentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	handles := []string{}
+	emails := []string{}
+	for _, mention := range mentions {
+		if mention.Email != "" {
+			emails = append(emails, mention.Email)
+		} else {
+			handles = append(handles, mention.Handle)
+		}
+	}
+
+	users, err := q.GetUsersByHandlesOrEmails(ctx, database.GetUsersByHandlesOrEmailsParams{
+		Handles: handles,
+		Emails:  emails,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		return err
+	}
+	byHandle := map[string]uuid.UUID{}
+	byEmail := map[string]uuid.UUID{}
+	for _, user := range users {
+		if user.Handle.Valid {
+			byHandle[user.Handle.String] = user.ID
+		}
+		byEmail[strings.ToLower(user.Email)] = user.ID
+	}
+
+	params := database.CreateChirpMentionsParams{ChirpID: chirp.ID}
+	for _, mention := range mentions {
+		userID, ok := byHandle[mention.Handle]
+		if mention.Email != "" {
+			userID, ok = byEmail[mention.Email]
+		}
+		if !ok {
+			continue
+		}
+		params.UserIDs = append(params.UserIDs, userID)
+		params.StartOffsets = append(params.StartOffsets, int32(mention.Start))
+		params.EndOffsets = append(params.EndOffsets, int32(mention.End))
+	}
+	if len(params.UserIDs) == 0 {
+		return nil
+	}
+
+	return q.CreateChirpMentions(ctx, params)
+}
+
+func (cfg *apiConfig) handlerGetMentions(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetChirpsMentioningUser(r.Context(), database.GetChirpsMentioningUserParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get mentions", err)
+		return
+	}
+
+	dbChirps, nextCursor := nextPage(dbChirps, page.limit, chirpCursor)
+	chirps, err := cfg.populateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+)
+
+const (
+	moderationDismiss     = "dismiss"
+	moderationHideChirp   = "hide_chirp"
+	moderationDeleteChirp = "delete_chirp"
+	moderationWarnUser    = "warn_user"
+	moderationSuspendUser = "suspend_user"
+	moderationFlagChirp   = "flag_chirp"
+)
+
+var (
+	errReportNotAboutChirp = errors.New("report isn't about a chirp")
+	errReportedChirpGone   = errors.New("reported chirp no longer exists")
+)
+
+// AdminReport is a report as moderators see it.
+type AdminReport struct {
+	Report
+	ReporterID       uuid.UUID  `json:"reporter_id"`
+	ChirpBody        *string    `json:"chirp_body"`
+	ClaimedBy        *uuid.UUID `json:"claimed_by"`
+	ClaimedAt        *time.Time `json:"claimed_at"`
+	ResolutionReason string     `json:"resolution_reason,omitempty"`
+}
+
+type ModerationAction struct {
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ModeratorID   *uuid.UUID `json:"moderator_id"`
+	ReportID      *uuid.UUID `json:"report_id"`
+	Action        string     `json:"action"`
+	Reason        string     `json:"reason"`
+	TargetUserID  uuid.UUID  `json:"target_user_id"`
+	TargetChirpID *uuid.UUID `json:"target_chirp_id"`
+}
+
+// Warning is a moderator's warning as the warned user sees it.
+type Warning struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Reason    string    `json:"reason"`
+}
+
+// handlerGetModerationQueue lists reports oldest first. By default that's
+// every report still waiting on a decision, claimed or not; ?status= narrows
+// it to open, claimed or resolved reports.
+func (cfg *apiConfig) handlerGetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Reports    []AdminReport `json:"reports"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}
+
+	if _, ok := cfg.authenticateAdmin(w, r); !ok {
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	var statuses []string
+	switch status := r.URL.Query().Get("status"); status {
+	case "":
+		statuses = []string{reportStatusOpen, reportStatusClaimed}
+	case reportStatusOpen, reportStatusClaimed, reportStatusResolved:
+		statuses = []string{status}
+	default:
+		respondWithError(w, http.StatusBadRequest, "status must be open, claimed or resolved", nil)
+		return
+	}
+
+	dbReports, err := cfg.db.GetReportsByStatus(r.Context(), database.GetReportsByStatusParams{
+		Statuses:        statuses,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get reports", err)
+		return
+	}
+
+	dbReports, nextCursor := nextPage(dbReports, page.limit, reportCursor)
+	reports := make([]AdminReport, len(dbReports))
+	for i, dbReport := range dbReports {
+		reports[i] = newAdminReport(dbReport)
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Reports:    reports,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerGetAdminReport(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.authenticateAdmin(w, r); !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report ID", err)
+		return
+	}
+
+	report, err := cfg.db.GetReportByID(r.Context(), reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find report", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newAdminReport(report))
+}
+
+// handlerClaimReport marks a report as being handled by the calling
+// moderator, so others in the queue can skip it. Claiming a report someone
+// else has claimed is a conflict.
+func (cfg *apiConfig) handlerClaimReport(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := cfg.authenticateAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report ID", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	report, ok := lockReportForModerator(w, r, qtx, reportID, adminID)
+	if !ok {
+		return
+	}
+
+	report, err = qtx.ClaimReport(r.Context(), database.ClaimReportParams{
+		ID:        report.ID,
+		ClaimedBy: uuid.NullUUID{UUID: adminID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't claim report", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newAdminReport(report))
+}
+
+// handlerResolveReport closes a report with one of the moderation actions
+// and records it, with the moderator and their reason, in the action log.
+func (cfg *apiConfig) handlerResolveReport(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Action string `json:"action"`
+		Reason string `json:"reason"`
+	}
+
+	adminID, ok := cfg.authenticateAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	switch params.Action {
+	case moderationDismiss, moderationHideChirp, moderationDeleteChirp, moderationWarnUser, moderationSuspendUser:
+	default:
+		respondWithError(w, http.StatusBadRequest, "Action must be dismiss, hide_chirp, delete_chirp, warn_user or suspend_user", nil)
+		return
+	}
+	reason := strings.TrimSpace(params.Reason)
+	if reason == "" {
+		respondWithError(w, http.StatusBadRequest, "A reason is required", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	report, ok := lockReportForModerator(w, r, qtx, reportID, adminID)
+	if !ok {
+		return
+	}
+
+	blobKeys, err := applyModerationAction(r.Context(), qtx, report, params.Action)
+	switch {
+	case errors.Is(err, errReportNotAboutChirp):
+		respondWithError(w, http.StatusBadRequest, "Report isn't about a chirp", err)
+		return
+	case errors.Is(err, errReportedChirpGone):
+		respondWithError(w, http.StatusConflict, "Reported chirp no longer exists", err)
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Couldn't apply moderation action", err)
+		return
+	}
+
+	moderatorID := uuid.NullUUID{UUID: adminID, Valid: true}
+	if _, err := qtx.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+		ModeratorID:   moderatorID,
+		ReportID:      uuid.NullUUID{UUID: report.ID, Valid: true},
+		Action:        params.Action,
+		Reason:        reason,
+		TargetUserID:  report.ReportedUserID,
+		TargetChirpID: report.ChirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record moderation action", err)
+		return
+	}
+
+	report, err = qtx.ResolveReport(r.Context(), database.ResolveReportParams{
+		ModeratorID:      moderatorID,
+		Resolution:       sql.NullString{String: params.Action, Valid: true},
+		ResolutionReason: sql.NullString{String: reason, Valid: true},
+		ID:               report.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve report", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+	cfg.deleteBlobs(blobKeys...)
+
+	respondWithJSON(w, http.StatusOK, newAdminReport(report))
+}
+
+// lockReportForModerator loads a report that adminID may work on: one that
+// isn't resolved and isn't claimed by another moderator. If it can't, the
+// error response has already been written and ok is false.
+func lockReportForModerator(w http.ResponseWriter, r *http.Request, q *database.Queries, reportID, adminID uuid.UUID) (database.Report, bool) {
+	report, err := q.GetReportByIDForUpdate(r.Context(), reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find report", err)
+		return database.Report{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get report", err)
+		return database.Report{}, false
+	}
+	if report.Status == reportStatusResolved {
+		respondWithError(w, http.StatusConflict, "Report is already resolved", nil)
+		return database.Report{}, false
+	}
+	if report.ClaimedBy.Valid && report.ClaimedBy.UUID != adminID {
+		respondWithError(w, http.StatusConflict, "Report is claimed by another moderator", nil)
+		return database.Report{}, false
+	}
+	return report, true
+}
+
+// applyModerationAction carries out action against the target of report.
+// A hidden chirp stays visible to its author, marked hidden, but no one
+// else can see it. A deleted chirp skips the trash and is purged at once.
+// Suspension signs the user out everywhere and stops them signing back in
+// or posting. It returns the keys of any files left to delete once the
+// transaction commits.
+func applyModerationAction(ctx context.Context, q *database.Queries, report database.Report, action string) ([]string, error) {
+	switch action {
+	case moderationHideChirp, moderationDeleteChirp:
+		if !report.ChirpID.Valid {
+			return nil, errReportNotAboutChirp
+		}
+		chirp, err := q.GetChirpByIDForUpdate(ctx, report.ChirpID.UUID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && chirp.TombstonedAt.Valid) {
+			return nil, errReportedChirpGone
+		}
+		if err != nil {
+			return nil, err
+		}
+		if action == moderationHideChirp {
+			return nil, hideChirp(ctx, q, chirp)
+		}
+		return deleteChirpNow(ctx, q, chirp)
+	case moderationSuspendUser:
+		if err := q.SuspendUser(ctx, report.ReportedUserID); err != nil {
+			return nil, err
+		}
+		return nil, q.RevokeUserRefreshTokens(ctx, report.ReportedUserID)
+	}
+	return nil, nil
+}
+
+// hideChirp takes a chirp out of public view, including its author's pins.
+// A published chirp in the trash has already stopped counting as a reply or
+// quote.
+func hideChirp(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if chirp.Status == chirpStatusHidden {
+		return nil
+	}
+	if err := q.HideChirp(ctx, chirp.ID); err != nil {
+		return err
+	}
+	if err := q.DeleteChirpPins(ctx, chirp.ID); err != nil {
+		return err
+	}
+	if chirp.Status != chirpStatusPublished || chirp.DeletedAt.Valid {
+		return nil
+	}
+	return uncountChirpReferences(ctx, q, chirp)
+}
+
+// handlerGetModerationActions lists the action log, newest first.
+// ?user_id= narrows it to actions taken against one user.
+func (cfg *apiConfig) handlerGetModerationActions(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Actions    []ModerationAction `json:"actions"`
+		NextCursor string             `json:"next_cursor,omitempty"`
+	}
+
+	if _, ok := cfg.authenticateAdmin(w, r); !ok {
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	targetUserID := uuid.NullUUID{}
+	if userIDString := r.URL.Query().Get("user_id"); userIDString != "" {
+		userID, err := uuid.Parse(userIDString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid user ID format", err)
+			return
+		}
+		targetUserID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	dbActions, err := cfg.db.GetModerationActions(r.Context(), database.GetModerationActionsParams{
+		TargetUserID:    targetUserID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get moderation actions", err)
+		return
+	}
+
+	dbActions, nextCursor := nextPage(dbActions, page.limit, moderationActionCursor)
+	actions := make([]ModerationAction, len(dbActions))
+	for i, dbAction := range dbActions {
+		actions[i] = newModerationAction(dbAction)
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Actions:    actions,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerGetWarnings lists the warnings moderators have given the user,
+// newest first.
+func (cfg *apiConfig) handlerGetWarnings(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Warnings   []Warning `json:"warnings"`
+		NextCursor string    `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	dbActions, err := cfg.db.GetModerationActions(r.Context(), database.GetModerationActionsParams{
+		TargetUserID:    uuid.NullUUID{UUID: userID, Valid: true},
+		Action:          sql.NullString{String: moderationWarnUser, Valid: true},
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get warnings", err)
+		return
+	}
+
+	dbActions, nextCursor := nextPage(dbActions, page.limit, moderationActionCursor)
+	warnings := make([]Warning, len(dbActions))
+	for i, dbAction := range dbActions {
+		warnings[i] = Warning{ID: dbAction.ID, CreatedAt: dbAction.CreatedAt, Reason: dbAction.Reason}
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Warnings:   warnings,
+		NextCursor: nextCursor,
+	})
+}
+
+// handlerFlagChirp sets a chirp's content warning and sensitive flag,
+// overriding whatever its author chose. It's recorded in the action log
+// like a report resolution.
+func (cfg *apiConfig) handlerFlagChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ContentWarning string `json:"content_warning"`
+		Sensitive      bool   `json:"sensitive"`
+		Reason         string `json:"reason"`
+	}
+
+	adminID, ok := cfg.authenticateAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	contentWarning, _, err := parseContentWarning(cfg.textValidator(), params.ContentWarning)
+	if err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+	reason := strings.TrimSpace(params.Reason)
+	if reason == "" {
+		respondWithError(w, http.StatusBadRequest, "A reason is required", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.TombstonedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp by ID", err)
+		return
+	}
+	if chirp.RechirpOfID.Valid {
+		respondWithError(w, http.StatusBadRequest, "Flag the rechirped chirp instead", nil)
+		return
+	}
+
+	chirp, err = qtx.SetChirpContentFlags(r.Context(), database.SetChirpContentFlagsParams{
+		ID:             chirp.ID,
+		ContentWarning: contentWarning,
+		Sensitive:      params.Sensitive,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't flag chirp", err)
+		return
+	}
+	if _, err := qtx.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+		ModeratorID:   uuid.NullUUID{UUID: adminID, Valid: true},
+		Action:        moderationFlagChirp,
+		Reason:        reason,
+		TargetUserID:  chirp.UserID,
+		TargetChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record moderation action", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	respChirp, err := cfg.populateChirp(r.Context(), uuid.NullUUID{UUID: adminID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, respChirp)
+}
+
+func moderationActionCursor(dbAction database.ModerationAction) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: dbAction.CreatedAt, ID: dbAction.ID}
+}
+
+func newAdminReport(dbReport database.Report) AdminReport {
+	report := AdminReport{
+		Report:           newReport(dbReport),
+		ReporterID:       dbReport.ReporterID,
+		ResolutionReason: dbReport.ResolutionReason.String,
+	}
+	if dbReport.ChirpBody.Valid {
+		report.ChirpBody = &dbReport.ChirpBody.String
+	}
+	if dbReport.ClaimedBy.Valid {
+		report.ClaimedBy = &dbReport.ClaimedBy.UUID
+	}
+	if dbReport.ClaimedAt.Valid {
+		report.ClaimedAt = &dbReport.ClaimedAt.Time
+	}
+	return report
+}
+
+func newModerationAction(dbAction database.ModerationAction) ModerationAction {
+	action := ModerationAction{
+		ID:           dbAction.ID,
+		CreatedAt:    dbAction.CreatedAt,
+		Action:       dbAction.Action,
+		Reason:       dbAction.Reason,
+		TargetUserID: dbAction.TargetUserID,
+	}
+	if dbAction.ModeratorID.Valid {
+		action.ModeratorID = &dbAction.ModeratorID.UUID
+	}
+	if dbAction.ReportID.Valid {
+		action.ReportID = &dbAction.ReportID.UUID
+	}
+	if dbAction.TargetChirpID.Valid {
+		action.TargetChirpID = &dbAction.TargetChirpID.UUID
+	}
+	return action
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/mutedwords"
+)
+
+// maxMutedWords caps how many live rules one user can have.
+const maxMutedWords = 500
+
+const (
+	mutedModeHide     = "hide"
+	mutedModeCollapse = "collapse"
+)
+
+type MutedWord struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Phrase    string     `json:"phrase"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (cfg *apiConfig) handlerGetMutedWords(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	dbWords, err := cfg.db.GetMutedWordsByUserID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get muted words", err)
+		return
+	}
+
+	words := make([]MutedWord, len(dbWords))
+	for i, dbWord := range dbWords {
+		words[i] = newMutedWord(dbWord)
+	}
+	respondWithJSON(w, http.StatusOK, words)
+}
+
+// handlerCreateMutedWord mutes a keyword, phrase or #hashtag. Muting
+// something that's already muted just replaces its expiry.
+func (cfg *apiConfig) handlerCreateMutedWord(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Phrase    string     `json:"phrase"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	phrase := mutedwords.Normalize(params.Phrase)
+	if phrase == "" {
+		respondWithError(w, http.StatusBadRequest, "Muted phrase must contain a word or a valid hashtag", nil)
+		return
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		respondWithError(w, http.StatusBadRequest, "Expiry must be in the future", nil)
+		return
+	}
+
+	count, err := cfg.db.CountOtherMutedWords(r.Context(), database.CountOtherMutedWordsParams{
+		UserID: userID,
+		Phrase: phrase,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count muted words", err)
+		return
+	}
+	if count >= maxMutedWords {
+		respondWithError(w, http.StatusBadRequest, "Too many muted words", nil)
+		return
+	}
+
+	dbWord, err := cfg.db.UpsertMutedWord(r.Context(), database.UpsertMutedWordParams{
+		UserID:    userID,
+		Phrase:    phrase,
+		ExpiresAt: nullTime(params.ExpiresAt),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute phrase", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newMutedWord(dbWord))
+}
+
+func (cfg *apiConfig) handlerDeleteMutedWord(w http.ResponseWriter, r *http.Request) {
+	mutedWordID, err := uuid.Parse(r.PathValue("mutedWordID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid muted word ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteMutedWord(r.Context(), database.DeleteMutedWordParams{
+		ID:     mutedWordID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete muted word", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Muted word not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseMutedMode reads how a chirp list should treat chirps matching the
+// viewer's muted words: leave them out (the default) or keep them with
+// muted_word set so the client can collapse them.
+func parseMutedMode(r *http.Request) (string, bool) {
+	switch mode := r.URL.Query().Get("muted"); mode {
+	case "", mutedModeHide:
+		return mutedModeHide, true
+	case mutedModeCollapse:
+		return mutedModeCollapse, true
+	default:
+		return "", false
+	}
+}
+
+// applyMutedWords hides or collapses the chirps in a list that match the
+// viewer's muted words, looking at the chirp's own text and the text of
+// any chirp it rechirps or quotes. Collapsing blanks all of them. The viewer's own chirps are left alone.
+// Hidden chirps make the page shorter, but the cursor still comes from the
+// unfiltered page, so paging carries on past them.
+func (cfg *apiConfig) applyMutedWords(ctx context.Context, viewerID uuid.NullUUID, chirps []Chirp, mode string) ([]Chirp, error) {
+	if !viewerID.Valid || len(chirps) == 0 {
+		return chirps, nil
+	}
+
+	dbWords, err := cfg.db.GetMutedWordsByUserID(ctx, viewerID.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbWords) == 0 {
+		return chirps, nil
+	}
+
+	rules := make([]string, len(dbWords))
+	for i, dbWord := range dbWords {
+		rules[i] = dbWord.Phrase
+	}
+	matcher := mutedwords.NewMatcher(rules)
+
+	texts := func(chirp Chirp) []string {
+		if chirp.UserID == viewerID.UUID {
+			return nil
+		}
+		return mutedTexts(chirp)
+	}
+	hide := func(chirp *Chirp, rule string) {
+		chirp.MutedWord = rule
+		for _, c := range []*Chirp{chirp, chirp.RechirpOf, chirp.QuotedChirp} {
+			if c != nil {
+				c.collapse()
+			}
+		}
+	}
+	return mutedwords.Filter(matcher, chirps, texts, mode == mutedModeCollapse, hide), nil
+}
+
+// mutedTexts is the text muted words are matched against: the chirp's own
+// and that of any chirp it rechirps or quotes, collapsed or not.
+func mutedTexts(chirp Chirp) []string {
+	texts := []string{}
+	for _, c := range []*Chirp{&chirp, chirp.RechirpOf, chirp.QuotedChirp} {
+		if c == nil {
+			continue
+		}
+		if c.Collapsed {
+			texts = append(texts, c.collapsedBody)
+		} else {
+			texts = append(texts, c.Body)
+		}
+	}
+	return texts
+}
+
+// deleteExpiredMutedWords clears out rules past their expiry. Reads already
+// ignore them; this just keeps the table small.
+func (cfg *apiConfig) deleteExpiredMutedWords(ctx context.Context) error {
+	deleted, err := cfg.db.DeleteExpiredMutedWords(ctx)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d expired muted words", deleted)
+	}
+	return nil
+}
+
+func newMutedWord(dbWord database.MutedWord) MutedWord {
+	word := MutedWord{
+		ID:        dbWord.ID,
+		CreatedAt: dbWord.CreatedAt,
+		Phrase:    dbWord.Phrase,
+	}
+	if dbWord.ExpiresAt.Valid {
+		word.ExpiresAt = &dbWord.ExpiresAt.Time
+	}
+	return word
+}
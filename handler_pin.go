@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+)
+
+// handlerPinChirp pins one of the user's own published chirps to the top of
+// their profile, after any already pinned. How many can be pinned depends
+// on the user's plan.
+func (cfg *apiConfig) handlerPinChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.TombstonedAt.Valid || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp by ID", err)
+		return
+	}
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Not authorized to pin this chirp", nil)
+		return
+	}
+	if chirp.RechirpOfID.Valid {
+		respondWithError(w, http.StatusBadRequest, "Rechirps can't be pinned", nil)
+		return
+	}
+	if chirp.Status != chirpStatusPublished {
+		respondWithError(w, http.StatusBadRequest, "Only published chirps can be pinned", nil)
+		return
+	}
+
+	limits, err := cfg.entitlements.limits(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get plan limits", err)
+		return
+	}
+	// Locking the user keeps two pins at once from both getting under the
+	// limit, or onto the same position.
+	if _, err := qtx.GetUserByIDForUpdate(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	pinned, err := qtx.GetPinnedChirpIDs(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get pinned chirps", err)
+		return
+	}
+	for _, id := range pinned {
+		if id == chirp.ID {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	if maxPinned := limits.maxPinnedChirps; len(pinned) >= maxPinned {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("You can pin at most %d chirps", maxPinned), nil)
+		return
+	}
+
+	if _, err := qtx.PinChirp(r.Context(), database.PinChirpParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't pin chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	if _, err := cfg.db.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unpin chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerReorderPinnedChirps puts the user's pinned chirps in the order
+// given, which must list each of them exactly once.
+func (cfg *apiConfig) handlerReorderPinnedChirps(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ChirpIDs []uuid.UUID `json:"chirp_ids"`
+	}
+	type response struct {
+		ChirpIDs []uuid.UUID `json:"chirp_ids"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if _, err := qtx.GetUserByIDForUpdate(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	pinned, err := qtx.GetPinnedChirpIDs(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get pinned chirps", err)
+		return
+	}
+	if !samePins(pinned, params.ChirpIDs) {
+		respondWithError(w, http.StatusBadRequest, "chirp_ids must list each pinned chirp once", nil)
+		return
+	}
+
+	if err := qtx.ReorderPinnedChirps(r.Context(), database.ReorderPinnedChirpsParams{
+		ChirpIDs: params.ChirpIDs,
+		UserID:   userID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reorder pinned chirps", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{ChirpIDs: params.ChirpIDs})
+}
+
+// samePins reports whether ordering is a rearrangement of pinned.
+func samePins(pinned, ordering []uuid.UUID) bool {
+	if len(pinned) != len(ordering) {
+		return false
+	}
+	remaining := make(map[uuid.UUID]bool, len(pinned))
+	for _, id := range pinned {
+		remaining[id] = true
+	}
+	for _, id := range ordering {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/validation"
+	"github.com/rivo/uniseg"
+)
+
+const (
+	minPollOptions         = 2
+	maxPollOptions         = 4
+	maxPollOptionLength    = 25
+	minPollDuration        = 5 * time.Minute
+	maxPollDuration        = 7 * 24 * time.Hour
+	finalizePollsBatchSize = 100
+)
+
+const (
+	pollStatusOpen   = "open"
+	pollStatusClosed = "closed"
+	pollStatusFinal  = "final"
+)
+
+type pollParameter struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+// Poll is a chirp's poll as the viewer sees it. Tallies are left out until
+// the viewer has voted or the poll has closed. A closed poll's tallies are
+// final once its status is "final".
+type Poll struct {
+	ClosesAt   time.Time    `json:"closes_at"`
+	Status     string       `json:"status"`
+	Options    []PollOption `json:"options"`
+	TotalVotes *int32       `json:"total_votes,omitempty"`
+	VotedFor   *int32       `json:"voted_for,omitempty"`
+}
+
+// PollOption is one answer in a poll. Options are numbered from 1.
+type PollOption struct {
+	Option int32  `json:"option"`
+	Text   string `json:"text"`
+	Votes  *int32 `json:"votes,omitempty"`
+}
+
+// validatePoll checks a poll for a chirp that will be published at opensAt.
+// Options are cleaned up by chain like the chirp's body, and come back that
+// way. hold is set if an option means the chirp has to be reviewed.
+func validatePoll(chain validation.ChirpChain, poll pollParameter, opensAt time.Time) (options []string, hold bool, err error) {
+	fieldErrs := validation.FieldErrors{}
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		fieldErrs = append(fieldErrs, validation.FieldError{
+			Field:   "poll.options",
+			Code:    "invalid",
+			Message: fmt.Sprintf("A poll must have %d to %d options", minPollOptions, maxPollOptions),
+		})
+	}
+
+	options = make([]string, len(poll.Options))
+	seen := make(map[string]bool, len(poll.Options))
+	for i, option := range poll.Options {
+		field := fmt.Sprintf("poll.options[%d]", i)
+		cleaned, optionHold, err := cleanText(chain, field, option)
+		if err != nil {
+			var errs validation.FieldErrors
+			if !errors.As(err, &errs) {
+				return nil, false, err
+			}
+			fieldErrs = append(fieldErrs, errs...)
+			continue
+		}
+		hold = hold || optionHold
+		options[i] = cleaned
+
+		key := strings.ToLower(options[i])
+		switch {
+		case options[i] == "":
+			fieldErrs = append(fieldErrs, validation.FieldError{
+				Field:   field,
+				Code:    "required",
+				Message: "Poll options can't be empty",
+			})
+		case uniseg.GraphemeClusterCount(options[i]) > maxPollOptionLength:
+			fieldErrs = append(fieldErrs, validation.FieldError{
+				Field:   field,
+				Code:    "too_long",
+				Message: fmt.Sprintf("Poll options can be at most %d characters", maxPollOptionLength),
+			})
+		case seen[key]:
+			fieldErrs = append(fieldErrs, validation.FieldError{
+				Field:   field,
+				Code:    "duplicate",
+				Message: "Poll options must all be different",
+			})
+		}
+		seen[key] = true
+	}
+
+	if poll.ClosesAt.Before(opensAt.Add(minPollDuration)) {
+		fieldErrs = append(fieldErrs, validation.FieldError{
+			Field:   "poll.closes_at",
+			Code:    "too_soon",
+			Message: fmt.Sprintf("A poll must stay open for at least %s", minPollDuration),
+		})
+	}
+	if poll.ClosesAt.After(opensAt.Add(maxPollDuration)) {
+		fieldErrs = append(fieldErrs, validation.FieldError{
+			Field:   "poll.closes_at",
+			Code:    "too_far",
+			Message: "A poll can stay open for at most a week",
+		})
+	}
+
+	if len(fieldErrs) > 0 {
+		return nil, false, fieldErrs
+	}
+	return options, hold, nil
+}
+
+// pollCanOpen reports whether chirp's poll, if it has one, can still stay
+// open for minPollDuration once the chirp goes out. A chirp held for review
+// may be approved after its poll was meant to close.
+func pollCanOpen(ctx context.Context, q *database.Queries, chirp database.Chirp) (bool, error) {
+	poll, err := q.GetPollByChirpIDForUpdate(ctx, chirp.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	opensAt := time.Now()
+	if chirp.PublishAt.Valid && chirp.PublishAt.Time.After(opensAt) {
+		opensAt = chirp.PublishAt.Time
+	}
+	return !poll.ClosesAt.Before(opensAt.Add(minPollDuration)), nil
+}
+
+// savePoll stores a validated poll for a new chirp.
+func savePoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, options []string, closesAt time.Time) error {
+	if _, err := q.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:  chirpID,
+		ClosesAt: closesAt.UTC(),
+	}); err != nil {
+		return err
+	}
+	for i, option := range options {
+		if err := q.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID:  chirpID,
+			Position: int32(i + 1),
+			Text:     option,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handlerVoteInPoll records the user's vote in a chirp's poll and returns
+// the poll with its tallies. Each user gets one vote, which can't be
+// changed. Voting on a rechirp votes in the poll of the chirp it reshares.
+func (cfg *apiConfig) handlerVoteInPoll(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Option int32 `json:"option"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := getOriginalChirp(r.Context(), qtx, chirpID, userID)
+	if err == nil && chirp.DeletedAt.Valid {
+		err = errors.New("chirp is in the trash")
+	}
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+
+	// Locking the poll keeps the finalizer from closing the books on it
+	// while the vote goes in.
+	poll, err := qtx.GetPollByChirpIDForUpdate(r.Context(), chirp.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Chirp doesn't have a poll", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get poll", err)
+		return
+	}
+	if poll.FinalizedAt.Valid || !poll.ClosesAt.After(time.Now()) {
+		respondWithError(w, http.StatusBadRequest, "Poll is closed", nil)
+		return
+	}
+	options, err := qtx.GetPollOptionsForChirps(r.Context(), []uuid.UUID{poll.ChirpID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get poll options", err)
+		return
+	}
+	if params.Option < 1 || int(params.Option) > len(options) {
+		respondWithError(w, http.StatusBadRequest, "Invalid poll option", nil)
+		return
+	}
+
+	err = qtx.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		ChirpID:  poll.ChirpID,
+		UserID:   userID,
+		Position: params.Option,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You've already voted in this poll", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record vote", err)
+		return
+	}
+	if err := qtx.IncrementPollOptionVoteCount(r.Context(), database.IncrementPollOptionVoteCountParams{
+		ChirpID:  poll.ChirpID,
+		Position: params.Option,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update poll tallies", err)
+		return
+	}
+	if err := qtx.IncrementPollVoteCount(r.Context(), poll.ChirpID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update poll tallies", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	respChirp, err := cfg.populateChirp(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load poll", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, respChirp.Poll)
+}
+
+// finalizeClosedPolls recounts the tallies of polls that have closed from
+// their votes and marks them final.
+func (cfg *apiConfig) finalizeClosedPolls(ctx context.Context) error {
+	for {
+		finalized, err := cfg.finalizeClosedPollBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if finalized < finalizePollsBatchSize {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) finalizeClosedPollBatch(ctx context.Context) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirpIDs, err := qtx.GetPollsToFinalize(ctx, finalizePollsBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(chirpIDs) == 0 {
+		return 0, nil
+	}
+	if err := qtx.RecountPollOptionVotes(ctx, chirpIDs); err != nil {
+		return 0, err
+	}
+	if err := qtx.FinalizePolls(ctx, chirpIDs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	log.Printf("Finalized %d closed polls", len(chirpIDs))
+	return len(chirpIDs), nil
+}
+
+// newPoll shows a poll to a viewer, who voted for votedFor if it's set.
+func newPoll(dbPoll database.Poll, dbOptions []database.PollOption, votedFor *int32) *Poll {
+	poll := &Poll{
+		ClosesAt: dbPoll.ClosesAt,
+		Status:   pollStatusOpen,
+		Options:  make([]PollOption, len(dbOptions)),
+		VotedFor: votedFor,
+	}
+	switch {
+	case dbPoll.FinalizedAt.Valid:
+		poll.Status = pollStatusFinal
+	case !dbPoll.ClosesAt.After(time.Now()):
+		poll.Status = pollStatusClosed
+	}
+
+	showResults := poll.Status != pollStatusOpen || votedFor != nil
+	if showResults {
+		poll.TotalVotes = &dbPoll.VoteCount
+	}
+	for i, dbOption := range dbOptions {
+		poll.Options[i] = PollOption{
+			Option: dbOption.Position,
+			Text:   dbOption.Text,
+		}
+		if showResults {
+			poll.Options[i].Votes = &dbOptions[i].VoteCount
+		}
+	}
+	return poll
+}
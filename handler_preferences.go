@@ -0,0 +1,110 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+)
+
+// Preferences are the user's account settings.
+type Preferences struct {
+	// ExpandSensitive shows chirps with a content warning or marked
+	// sensitive in full in lists, instead of collapsed.
+	ExpandSensitive bool `json:"expand_sensitive"`
+	// AutoDeleteAfterDays has the user's chirps deleted once they're this
+	// many days old. Null keeps them.
+	AutoDeleteAfterDays *int32 `json:"auto_delete_after_days"`
+}
+
+func (cfg *apiConfig) handlerGetPreferences(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newPreferences(user))
+}
+
+func (cfg *apiConfig) handlerUpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	// Settings left out are kept as they are. An auto_delete_after_days
+	// of 0 turns auto-delete off.
+	type parameters struct {
+		ExpandSensitive     *bool  `json:"expand_sensitive"`
+		AutoDeleteAfterDays *int32 `json:"auto_delete_after_days"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	if params.ExpandSensitive == nil && params.AutoDeleteAfterDays == nil {
+		respondWithError(w, http.StatusBadRequest, "No preferences to update", nil)
+		return
+	}
+	if days := params.AutoDeleteAfterDays; days != nil && (*days < 0 || *days > maxAutoDeleteDays) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("auto_delete_after_days must be between 0 and %d", maxAutoDeleteDays), nil)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	updateParams := database.UpdateUserPreferencesParams{
+		ID:                  userID,
+		ExpandSensitive:     user.ExpandSensitive,
+		AutoDeleteAfterDays: user.AutoDeleteAfterDays,
+	}
+	if params.ExpandSensitive != nil {
+		updateParams.ExpandSensitive = *params.ExpandSensitive
+	}
+	if days := params.AutoDeleteAfterDays; days != nil {
+		updateParams.AutoDeleteAfterDays = sql.NullInt32{Int32: *days, Valid: *days > 0}
+	}
+
+	user, err = cfg.db.UpdateUserPreferences(r.Context(), updateParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update preferences", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newPreferences(user))
+}
+
+func newPreferences(user database.User) Preferences {
+	preferences := Preferences{ExpandSensitive: user.ExpandSensitive}
+	if user.AutoDeleteAfterDays.Valid {
+		preferences.AutoDeleteAfterDays = &user.AutoDeleteAfterDays.Int32
+	}
+	return preferences
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+)
+
+const (
+	likeReaction = "like"
+	// defaultReactionEmoji is the emoji set used when CHIRP_REACTIONS isn't set.
+	defaultReactionEmoji = "❤️,😂,😮,😢,🔥"
+)
+
+type ReactionSummary struct {
+	Count   int32 `json:"count"`
+	Reacted bool  `json:"reacted"`
+}
+
+// parseReactions builds the set of allowed reactions from a comma-separated
+// emoji list. A like is always allowed.
+func parseReactions(emoji string) map[string]struct{} {
+	reactions := map[string]struct{}{likeReaction: {}}
+	for _, e := range strings.Split(emoji, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			reactions[e] = struct{}{}
+		}
+	}
+	return reactions
+}
+
+func (cfg *apiConfig) handlerAddReaction(w http.ResponseWriter, r *http.Request) {
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	reaction := r.PathValue("reaction")
+	if _, ok := cfg.reactions[reaction]; !ok {
+		respondWithError(w, http.StatusBadRequest, "Unsupported reaction", nil)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := getOriginalChirp(r.Context(), qtx, chirpID, userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp to react to", err)
+		return
+	}
+
+	added, err := qtx.CreateChirpReaction(r.Context(), database.CreateChirpReactionParams{
+		ChirpID:  chirp.ID,
+		UserID:   userID,
+		Reaction: reaction,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't add reaction", err)
+		return
+	}
+	// Reacting twice is a no-op, so only count the first time.
+	if added > 0 {
+		if err := qtx.IncrementChirpReactionCount(r.Context(), database.IncrementChirpReactionCountParams{
+			Reaction: reaction,
+			ID:       chirp.ID,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update reaction count", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRemoveReaction(w http.ResponseWriter, r *http.Request) {
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	reaction := r.PathValue("reaction")
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := getOriginalChirp(r.Context(), qtx, chirpID, userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+
+	removed, err := qtx.DeleteChirpReaction(r.Context(), database.DeleteChirpReactionParams{
+		ChirpID:  chirp.ID,
+		UserID:   userID,
+		Reaction: reaction,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove reaction", err)
+		return
+	}
+	if removed > 0 {
+		if err := qtx.DecrementChirpReactionCount(r.Context(), database.DecrementChirpReactionCountParams{
+			Reaction: reaction,
+			ID:       chirp.ID,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update reaction count", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+)
+
+const maxReportDetailsLength = 1000
+
+const (
+	reportStatusOpen     = "open"
+	reportStatusClaimed  = "claimed"
+	reportStatusResolved = "resolved"
+)
+
+// reportReasons are the categories a report can be filed under.
+var reportReasons = map[string]bool{
+	"spam":          true,
+	"harassment":    true,
+	"hate":          true,
+	"violence":      true,
+	"self_harm":     true,
+	"sexual":        true,
+	"impersonation": true,
+	"other":         true,
+}
+
+// Report is a report as its reporter sees it. Who handled it and why stay
+// with the moderators; the reporter only learns the outcome.
+type Report struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ReportedUserID uuid.UUID  `json:"reported_user_id"`
+	ChirpID        *uuid.UUID `json:"chirp_id"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details"`
+	Status         string     `json:"status"`
+	Resolution     string     `json:"resolution,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+}
+
+// handlerCreateReport reports a chirp or a user to the moderators. A chirp
+// report is also a report of its author. Reporting a rechirp reports the
+// chirp it reshares.
+func (cfg *apiConfig) handlerCreateReport(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ChirpID *uuid.UUID `json:"chirp_id"`
+		UserID  *uuid.UUID `json:"user_id"`
+		Reason  string     `json:"reason"`
+		Details string     `json:"details"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if (params.ChirpID == nil) == (params.UserID == nil) {
+		respondWithError(w, http.StatusBadRequest, "Report either a chirp_id or a user_id", nil)
+		return
+	}
+	if !reportReasons[params.Reason] {
+		respondWithError(w, http.StatusBadRequest, "Invalid report reason", nil)
+		return
+	}
+	if utf8.RuneCountInString(params.Details) > maxReportDetailsLength {
+		respondWithError(w, http.StatusBadRequest, "Report details are too long", nil)
+		return
+	}
+
+	reportParams := database.CreateReportParams{
+		ReporterID: userID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+	}
+	if params.ChirpID != nil {
+		chirp, err := getOriginalChirp(r.Context(), cfg.db, *params.ChirpID, userID)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp to report", err)
+			return
+		}
+		// Keep the text as it was reported, in case it's edited or
+		// deleted before a moderator gets to it.
+		reportParams.ReportedUserID = chirp.UserID
+		reportParams.ChirpID = uuid.NullUUID{UUID: chirp.ID, Valid: true}
+		reportParams.ChirpBody = sql.NullString{String: chirp.Body, Valid: true}
+	} else {
+		userExists, err := cfg.db.UserExists(r.Context(), *params.UserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check if user exists", err)
+			return
+		}
+		if !userExists {
+			respondWithError(w, http.StatusNotFound, "User not found", nil)
+			return
+		}
+		reportParams.ReportedUserID = *params.UserID
+	}
+	if reportParams.ReportedUserID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't report yourself", nil)
+		return
+	}
+
+	report, err := cfg.db.CreateReport(r.Context(), reportParams)
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You've already reported this", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newReport(report))
+}
+
+// handlerGetReports lists the reports the user has filed, newest first.
+func (cfg *apiConfig) handlerGetReports(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Reports    []Report `json:"reports"`
+		NextCursor string   `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	dbReports, err := cfg.db.GetReportsByReporterID(r.Context(), database.GetReportsByReporterIDParams{
+		ReporterID:      userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get reports", err)
+		return
+	}
+
+	dbReports, nextCursor := nextPage(dbReports, page.limit, reportCursor)
+	reports := make([]Report, len(dbReports))
+	for i, dbReport := range dbReports {
+		reports[i] = newReport(dbReport)
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Reports:    reports,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerGetReport(w http.ResponseWriter, r *http.Request) {
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	report, err := cfg.db.GetReportByID(r.Context(), reportID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && report.ReporterID != userID) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find report", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newReport(report))
+}
+
+func reportCursor(dbReport database.Report) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: dbReport.CreatedAt, ID: dbReport.ID}
+}
+
+func newReport(dbReport database.Report) Report {
+	report := Report{
+		ID:             dbReport.ID,
+		CreatedAt:      dbReport.CreatedAt,
+		UpdatedAt:      dbReport.UpdatedAt,
+		ReportedUserID: dbReport.ReportedUserID,
+		Reason:         dbReport.Reason,
+		Details:        dbReport.Details,
+		Status:         dbReport.Status,
+		Resolution:     dbReport.Resolution.String,
+	}
+	if dbReport.ChirpID.Valid {
+		report.ChirpID = &dbReport.ChirpID.UUID
+	}
+	if dbReport.ResolvedAt.Valid {
+		report.ResolvedAt = &dbReport.ResolvedAt.Time
+	}
+	return report
+}
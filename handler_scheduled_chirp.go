@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+	"github.com/katsuikeda/chirpy/internal/validation"
+)
+
+const (
+	maxScheduleAhead = 365 * 24 * time.Hour
+	publishBatchSize = 100
+)
+
+// validatePublishAt checks the time a chirp is scheduled for.
+func validatePublishAt(publishAt time.Time) error {
+	now := time.Now()
+	if !publishAt.After(now) {
+		return validation.FieldErrors{{Field: "publish_at", Code: "in_past", Message: "publish_at must be in the future"}}
+	}
+	if publishAt.After(now.Add(maxScheduleAhead)) {
+		return validation.FieldErrors{{Field: "publish_at", Code: "too_far", Message: "publish_at can be at most a year ahead"}}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handlerGetScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetScheduledChirpsByUserID(r.Context(), database.GetScheduledChirpsByUserIDParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get scheduled chirps", err)
+		return
+	}
+
+	// Scheduled chirps are listed in the order they'll be published.
+	dbChirps, nextCursor := nextPage(dbChirps, page.limit, func(dbChirp database.Chirp) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: dbChirp.PublishAt.Time, ID: dbChirp.ID}
+	})
+	chirps, err := cfg.populateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerRescheduleChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		PublishAt time.Time `json:"publish_at"`
+	}
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	if err := validatePublishAt(params.PublishAt); err != nil {
+		respondWithValidationError(w, err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.UserID != userID || chirp.Status != chirpStatusScheduled || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't find scheduled chirp", err)
+		return
+	}
+
+	rescheduled, err := qtx.RescheduleChirp(r.Context(), database.RescheduleChirpParams{
+		ID:        chirp.ID,
+		PublishAt: sql.NullTime{Time: params.PublishAt.UTC(), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reschedule chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	respChirp, err := cfg.populateChirp(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, rescheduled)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, respChirp)
+}
+
+// handlerCancelScheduledChirp moves a chirp that hasn't been published yet
+// to the trash. Restoring it puts it back on the schedule.
+func (cfg *apiConfig) handlerCancelScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find bearer token in request header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByIDForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.UserID != userID || chirp.Status != chirpStatusScheduled || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't find scheduled chirp", err)
+		return
+	}
+	if err := trashChirp(r.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publishDueChirps publishes every scheduled chirp whose time has come, in
+// batches. Rows another instance is already publishing are skipped rather
+// than waited for, so instances never publish the same chirp twice.
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) error {
+	for {
+		published, err := cfg.publishDueChirpBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if published < publishBatchSize {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) publishDueChirpBatch(ctx context.Context) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirps, err := qtx.PublishDueChirps(ctx, publishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, chirp := range chirps {
+		// A published chirp is new as of now, so it shows up at the top of
+		// timelines, including hashtag ones.
+		if err := qtx.UpdateChirpHashtagsCreatedAt(ctx, database.UpdateChirpHashtagsCreatedAtParams{
+			ChirpID:   chirp.ID,
+			CreatedAt: chirp.PublishedAt,
+		}); err != nil {
+			return 0, err
+		}
+		if err := countChirpReferences(ctx, qtx, chirp); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	if len(chirps) > 0 {
+		log.Printf("Published %d scheduled chirps", len(chirps))
+	}
+	return len(chirps), nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+	"github.com/katsuikeda/chirpy/internal/search"
+)
+
+const (
+	searchSortRelevance = "relevance"
+	searchSortAsc       = "asc"
+	searchSortDesc      = "desc"
+)
+
+// SearchResult is a chirp that matched a search. Snippet is HTML: the
+// escaped text around the matches, which are wrapped in <mark> tags. It's
+// left out for collapsed chirps, whose body it would give away.
+type SearchResult struct {
+	Chirp
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []SearchResult `json:"chirps"`
+		NextCursor string         `json:"next_cursor,omitempty"`
+	}
+
+	tsQuery, err := search.ToTSQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid search query", err)
+		return
+	}
+
+	viewerID, err := cfg.getViewerID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	// Results are ranked by relevance unless they're asked for by date.
+	sort := r.URL.Query().Get("sort")
+	switch sort {
+	case "":
+		sort = searchSortRelevance
+	case searchSortRelevance, searchSortAsc, searchSortDesc:
+	default:
+		respondWithError(w, http.StatusBadRequest, "Sort must be relevance, asc or desc", nil)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if authorIDString := r.URL.Query().Get("author_id"); authorIDString != "" {
+		authorID.UUID, err = uuid.Parse(authorIDString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid author ID format", err)
+			return
+		}
+		authorID.Valid = true
+
+		userExists, err := cfg.db.UserExists(r.Context(), authorID.UUID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check if user exists", err)
+			return
+		}
+		if !userExists {
+			respondWithError(w, http.StatusNotFound, "User not found", nil)
+			return
+		}
+	}
+
+	rows, err := cfg.searchChirps(r.Context(), sort, database.SearchChirpsParams{
+		Query:           tsQuery,
+		AuthorID:        authorID,
+		CursorRank:      page.cursorRank(),
+		CursorCreatedAt: page.cursorCreatedAt(),
+		CursorID:        page.cursorID(),
+		Limit:           page.fetchLimit(),
+		ViewerID:        viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't search chirps", err)
+		return
+	}
+
+	rows, nextCursor := nextPage(rows, page.limit, func(row database.SearchChirpsRow) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: row.Chirp.PublishedAt, ID: row.Chirp.ID, Rank: row.Rank}
+	})
+
+	dbChirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		dbChirps[i] = row.Chirp
+	}
+	chirps, err := cfg.populateChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirps", err)
+		return
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{
+			Chirp: chirps[i],
+			Rank:  row.Rank,
+		}
+		if !chirps[i].Collapsed {
+			results[i].Snippet = row.Snippet
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Chirps:     results,
+		NextCursor: nextCursor,
+	})
+}
+
+// searchChirps runs a search in the given order. Date orders ignore the
+// cursor's rank.
+func (cfg *apiConfig) searchChirps(ctx context.Context, sort string, params database.SearchChirpsParams) ([]database.SearchChirpsRow, error) {
+	byDate := database.SearchChirpsByDateParams{
+		Query:           params.Query,
+		ViewerID:        params.ViewerID,
+		AuthorID:        params.AuthorID,
+		CursorCreatedAt: params.CursorCreatedAt,
+		CursorID:        params.CursorID,
+		Limit:           params.Limit,
+	}
+
+	switch sort {
+	case searchSortAsc:
+		dateRows, err := cfg.db.SearchChirpsByDate(ctx, byDate)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]database.SearchChirpsRow, len(dateRows))
+		for i, row := range dateRows {
+			rows[i] = database.SearchChirpsRow(row)
+		}
+		return rows, nil
+	case searchSortDesc:
+		dateRows, err := cfg.db.SearchChirpsByDateDesc(ctx, database.SearchChirpsByDateDescParams(byDate))
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]database.SearchChirpsRow, len(dateRows))
+		for i, row := range dateRows {
+			rows[i] = database.SearchChirpsRow(row)
+		}
+		return rows, nil
+	default:
+		return cfg.db.SearchChirps(ctx, params)
+	}
+}
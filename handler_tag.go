@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/katsuikeda/chirpy/internal/chirptext"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+)
+
+const (
+	trendingTagsWindow   = 24 * time.Hour
+	trendingTagsHalfLife = 6 * time.Hour
+	maxTrendingTags      = 50
+)
+
+type TrendingTag struct {
+	Tag        string    `json:"tag"`
+	Score      float64   `json:"score"`
+	ChirpCount int32     `json:"chirp_count"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// saveChirpHashtags indexes the hashtags in a chirp's body. Tags are stored
+// with the chirp's creation time so tag timelines page like the main one.
+func saveChirpHashtags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	tags := chirptext.Hashtags(chirp.Body)
+	if len(tags) == 0 {
+		return nil
+	}
+	return q.CreateChirpHashtags(ctx, database.CreateChirpHashtagsParams{
+		ChirpID:   chirp.ID,
+		Tags:      tags,
+		CreatedAt: chirp.PublishedAt,
+	})
+}
+
+func (cfg *apiConfig) handlerGetChirpsByHashtag(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Tag        string  `json:"tag"`
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	tag := chirptext.NormalizeHashtag(r.PathValue("tag"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid hashtag", nil)
+		return
+	}
+
+	viewerID, err := cfg.getViewerID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	var dbChirps []database.Chirp
+	if page.descending {
+		dbChirps, err = cfg.db.GetChirpsByHashtagDesc(r.Context(), database.GetChirpsByHashtagDescParams{
+			Tag:             tag,
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			Limit:           page.fetchLimit(),
+			ViewerID:        viewerID,
+		})
+	} else {
+		dbChirps, err = cfg.db.GetChirpsByHashtag(r.Context(), database.GetChirpsByHashtagParams{
+			Tag:             tag,
+			CursorCreatedAt: page.cursorCreatedAt(),
+			CursorID:        page.cursorID(),
+			Limit:           page.fetchLimit(),
+			ViewerID:        viewerID,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps", err)
+		return
+	}
+
+	dbChirps, nextCursor := nextPage(dbChirps, page.limit, chirpCursor)
+	chirps, err := cfg.populateChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Tag:        tag,
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerGetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Tags []TrendingTag `json:"tags"`
+	}
+
+	limit, err := pagination.ParseLimit(r.URL.Query().Get("limit"), 10, maxTrendingTags)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+
+	dbTags, err := cfg.db.GetTrendingTags(r.Context(), int32(limit))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get trending tags", err)
+		return
+	}
+
+	tags := make([]TrendingTag, len(dbTags))
+	for i, dbTag := range dbTags {
+		tags[i] = TrendingTag{
+			Tag:        dbTag.Tag,
+			Score:      dbTag.Score,
+			ChirpCount: dbTag.ChirpCount,
+			ComputedAt: dbTag.ComputedAt,
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Tags: tags,
+	})
+}
+
+// refreshTrendingTags recomputes the trending list from recent hashtag use.
+// Each use is worth less the older it is, halving every trendingTagsHalfLife,
+// so a burst of fresh chirps outranks a tag that has been steady all day.
+// If another instance is already refreshing the list, this one leaves it be.
+func (cfg *apiConfig) refreshTrendingTags(ctx context.Context) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	locked, err := qtx.LockTrendingTagsRefresh(ctx)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	if err := qtx.DeleteTrendingTags(ctx); err != nil {
+		return err
+	}
+	if err := qtx.ComputeTrendingTags(ctx, database.ComputeTrendingTagsParams{
+		HalfLifeSeconds: trendingTagsHalfLife.Seconds(),
+		WindowSeconds:   trendingTagsWindow.Seconds(),
+		Limit:           maxTrendingTags,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
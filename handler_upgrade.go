@@ -1,17 +1,48 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/katsuikeda/chirpy/internal/auth"
+	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/subscription"
 )
 
-func (cfg *apiConfig) HandlerUpgradeUserToChirpyRed(w http.ResponseWriter, r *http.Request) {
+const expireSubscriptionsBatchSize = 100
+
+// Subscription events Polka sends about a user's Chirpy Red subscription.
+const (
+	polkaEventUpgraded      = "user.upgraded"
+	polkaEventTrialStarted  = "user.trial_started"
+	polkaEventPaymentFailed = "user.payment_failed"
+	polkaEventCanceled      = "user.canceled"
+)
+
+var polkaEvents = map[string]subscription.Event{
+	polkaEventUpgraded:      subscription.EventPaid,
+	polkaEventTrialStarted:  subscription.EventTrialStarted,
+	polkaEventPaymentFailed: subscription.EventPaymentFailed,
+	polkaEventCanceled:      subscription.EventCanceled,
+}
+
+// handlerPolkaWebhooks keeps users' Chirpy Red subscriptions in step with
+// Polka, our payment provider. An upgrade starts a subscription or pays
+// for the next period of the current one. Events that don't change
+// anything, such as a second cancellation, are acknowledged so Polka
+// doesn't retry them. Polka may deliver an event more than once, so one
+// with an ID is applied only the first time that ID is seen. Events
+// without an ID are still accepted; an upgrade for a user who's already
+// paid up is then taken to be a repeat.
+func (cfg *apiConfig) handlerPolkaWebhooks(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
+		ID    string `json:"id"`
 		Event string `json:"event"`
 		Data  struct {
 			UserID uuid.UUID `json:"user_id"`
@@ -35,18 +66,135 @@ func (cfg *apiConfig) HandlerUpgradeUserToChirpyRed(w http.ResponseWriter, r *ht
 		return
 	}
 
-	if params.Event != "user.upgraded" {
+	event, ok := polkaEvents[params.Event]
+	if !ok {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	if err := cfg.db.UpgradeUserToChirpyRed(r.Context(), params.Data.UserID); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Locking the user applies their events one at a time.
+	_, err = qtx.GetUserByIDForUpdate(r.Context(), params.Data.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	if params.ID != "" {
+		recorded, err := qtx.RecordPolkaEvent(r.Context(), database.RecordPolkaEventParams{
+			ID:     params.ID,
+			UserID: params.Data.UserID,
+			Event:  params.Event,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't record event", err)
+			return
+		}
+		if recorded == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	current, err := qtx.GetCurrentSubscriptionByUserIDForUpdate(r.Context(), params.Data.UserID)
+	hasCurrent := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get subscription", err)
+		return
+	}
+
+	var currentState *subscription.Subscription
+	if hasCurrent {
+		s := newSubscriptionState(current)
+		currentState = &s
+	}
+	state, err := cfg.subscriptionTerms.Apply(currentState, event, time.Now().UTC(), params.ID != "")
+	if errors.Is(err, subscription.ErrNoChange) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err == nil {
+		if hasCurrent {
+			err = updateSubscription(r.Context(), qtx, current.ID, state)
+		} else {
+			err = createSubscription(r.Context(), qtx, params.Data.UserID, state)
 		}
-		respondWithError(w, http.StatusInternalServerError, "Couldn't upgrade user", err)
+	}
+	if isUniqueViolation(err) {
+		// Another event started a subscription first. The lock on the
+		// user should rule that out, but acknowledging it is still safe.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update subscription", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't commit transaction", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func createSubscription(ctx context.Context, q *database.Queries, userID uuid.UUID, state subscription.Subscription) error {
+	_, err := q.CreateSubscription(ctx, database.CreateSubscriptionParams{
+		UserID:             userID,
+		Plan:               planChirpyRed,
+		Status:             state.Status,
+		CurrentPeriodStart: state.CurrentPeriodStart.UTC(),
+		CurrentPeriodEnd:   state.CurrentPeriodEnd.UTC(),
+		TrialEndsAt:        nullTimeOf(state.TrialEndsAt),
+		GracePeriodEndsAt:  state.GracePeriodEndsAt.UTC(),
+	})
+	return err
+}
+
+func updateSubscription(ctx context.Context, q *database.Queries, id uuid.UUID, state subscription.Subscription) error {
+	_, err := q.UpdateSubscription(ctx, database.UpdateSubscriptionParams{
+		ID:                 id,
+		Status:             state.Status,
+		CurrentPeriodStart: state.CurrentPeriodStart.UTC(),
+		CurrentPeriodEnd:   state.CurrentPeriodEnd.UTC(),
+		TrialEndsAt:        nullTimeOf(state.TrialEndsAt),
+		GracePeriodEndsAt:  state.GracePeriodEndsAt.UTC(),
+		CanceledAt:         nullTimeOf(state.CanceledAt),
+	})
+	return err
+}
+
+// expireLapsedSubscriptions marks subscriptions that no longer grant their
+// plan as expired, in batches, which moves their users back to the free
+// plan for good.
+func (cfg *apiConfig) expireLapsedSubscriptions(ctx context.Context) error {
+	for {
+		expired, err := cfg.db.ExpireLapsedSubscriptions(ctx, expireSubscriptionsBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(expired) > 0 {
+			log.Printf("Expired %d lapsed subscriptions", len(expired))
+		}
+		if len(expired) < expireSubscriptionsBatchSize {
+			return nil
+		}
+	}
+}
+
+// nullTimeOf stores a zero time as NULL.
+func nullTimeOf(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
@@ -0,0 +1,24 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore keeps uploaded files under opaque keys. Keys are made of
+// letters, digits, dots, dashes, underscores and slashes, so the same key
+// works for a directory on disk or an S3-compatible bucket.
+type BlobStore interface {
+	// Put stores everything read from r under key, replacing any blob
+	// already stored there.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the blob stored under key. It returns ErrNotFound if
+	// there isn't one. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a missing blob
+	// isn't an error.
+	Delete(ctx context.Context, key string) error
+}
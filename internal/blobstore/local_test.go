@@ -0,0 +1,72 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() unexpected error: %v", err)
+	}
+
+	if err := store.Put(ctx, "media/abc.jpg", strings.NewReader("first")); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	if err := store.Put(ctx, "media/abc.jpg", strings.NewReader("second")); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+
+	r, err := store.Get(ctx, "media/abc.jpg")
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("ReadAll() unexpected error: %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("Get() = %q, want %q", data, "second")
+	}
+
+	if err := store.Delete(ctx, "media/abc.jpg"); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+	if _, err := store.Get(ctx, "media/abc.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want %v", err, ErrNotFound)
+	}
+	if err := store.Delete(ctx, "media/abc.jpg"); err != nil {
+		t.Errorf("Delete() of missing blob unexpected error: %v", err)
+	}
+}
+
+func TestLocalStoreRejectsInvalidKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() unexpected error: %v", err)
+	}
+
+	keys := []string{
+		"",
+		"/etc/passwd",
+		"../outside",
+		"media/../../outside",
+		"media//abc",
+		"media/",
+		`media\abc`,
+		"media/.hidden",
+	}
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			if err := store.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+				t.Errorf("Put(%q) expected error but got none", key)
+			}
+		})
+	}
+}
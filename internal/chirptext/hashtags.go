@@ -0,0 +1,70 @@
+package chirptext
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxHashtagLength = 100
+
+// Hashtags returns the normalized #hashtags in body, in order of first
+// appearance and without duplicates. A hashtag is a # that doesn't follow a
+// letter or digit, followed by letters, digits, marks or underscores, at
+// least one of which is a letter. "#1" and "a#b" are not hashtags.
+func Hashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]struct{}{}
+
+	prev := rune(-1)
+	for i := 0; i < len(body); {
+		r, size := utf8.DecodeRuneInString(body[i:])
+		if r != '#' || isTagRune(prev) {
+			prev = r
+			i += size
+			continue
+		}
+
+		end := i + size
+		hasLetter := false
+		for end < len(body) {
+			next, nextSize := utf8.DecodeRuneInString(body[end:])
+			if !isTagRune(next) {
+				break
+			}
+			hasLetter = hasLetter || unicode.IsLetter(next)
+			end += nextSize
+		}
+
+		if tag := NormalizeHashtag(body[i:end]); hasLetter && tag != "" {
+			if _, ok := seen[tag]; !ok {
+				seen[tag] = struct{}{}
+				tags = append(tags, tag)
+			}
+		}
+
+		prev, _ = utf8.DecodeLastRuneInString(body[:end])
+		i = end
+	}
+
+	return tags
+}
+
+// NormalizeHashtag turns "#GoLang" or "golang" into the stored form "golang".
+// It returns "" for text that can't be a hashtag.
+func NormalizeHashtag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" || utf8.RuneCountInString(tag) > maxHashtagLength {
+		return ""
+	}
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return ""
+		}
+	}
+	return tag
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
@@ -0,0 +1,100 @@
+package chirptext
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "No Hashtags",
+			body: "just a chirp",
+			want: []string{},
+		},
+		{
+			name: "Single Hashtag",
+			body: "learning #golang today",
+			want: []string{"golang"},
+		},
+		{
+			name: "Case Folded And Deduplicated",
+			body: "#Go is #go and #GO",
+			want: []string{"go"},
+		},
+		{
+			name: "Trailing Punctuation",
+			body: "ship it #friday!",
+			want: []string{"friday"},
+		},
+		{
+			name: "Unicode Letters",
+			body: "#日本語 と #café",
+			want: []string{"日本語", "café"},
+		},
+		{
+			name: "Combining Marks",
+			body: "#café",
+			want: []string{"café"},
+		},
+		{
+			name: "Digits Only Is Not A Tag",
+			body: "we're #1",
+			want: []string{},
+		},
+		{
+			name: "Letter Before Hash",
+			body: "a#b c#d",
+			want: []string{},
+		},
+		{
+			name: "Underscores And Digits",
+			body: "#go_1_23",
+			want: []string{"go_1_23"},
+		},
+		{
+			name: "Adjacent Hashtags",
+			body: "#one#two",
+			want: []string{"one"},
+		},
+		{
+			name: "Too Long",
+			body: "#" + strings.Repeat("a", 101),
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hashtags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{tag: "#GoLang", want: "golang"},
+		{tag: "golang", want: "golang"},
+		{tag: "#", want: ""},
+		{tag: "go lang", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			if got := NormalizeHashtag(tt.tag); got != tt.want {
+				t.Errorf("NormalizeHashtag(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package chirptext
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxHandleLength = 30
+
+// Mention is an @reference in a chirp body. Start and End are character
+// (rune) offsets into the body, End exclusive, and span the leading @.
+type Mention struct {
+	// Handle is set for @handle mentions and Email for @email mentions,
+	// both lowercased.
+	Handle string
+	Email  string
+	Start  int
+	End    int
+}
+
+// Mentions returns the @handle and @user@example.com references in body, in
+// order. Like a hashtag, a mention can't directly follow a letter or digit,
+// so the "@" inside a plain email address doesn't start one.
+func Mentions(body string) []Mention {
+	mentions := []Mention{}
+
+	prev := rune(-1)
+	runeIndex := 0
+	for i := 0; i < len(body); {
+		r, size := utf8.DecodeRuneInString(body[i:])
+		if r != '@' || isMentionBoundary(prev) {
+			prev = r
+			i += size
+			runeIndex++
+			continue
+		}
+
+		// Text after @ is ASCII only, so byte lengths below equal rune counts.
+		rest := body[i+size:]
+		mention := Mention{Start: runeIndex}
+		length := 0
+		if email := leadingEmail(rest); email != "" {
+			mention.Email = strings.ToLower(email)
+			length = len(email)
+		} else if handle := leadingHandle(rest); handle != "" {
+			mention.Handle = strings.ToLower(handle)
+			length = len(handle)
+		}
+
+		if length == 0 {
+			prev = r
+			i += size
+			runeIndex++
+			continue
+		}
+
+		mention.End = mention.Start + 1 + length
+		mentions = append(mentions, mention)
+
+		i += size + length
+		runeIndex = mention.End
+		prev = rune(body[i-1])
+	}
+
+	return mentions
+}
+
+// NormalizeHandle lowercases a handle such as "@Gopher" to "gopher". It
+// returns "" for text that isn't a valid handle: 1 to 30 ASCII letters,
+// digits or underscores.
+func NormalizeHandle(handle string) string {
+	handle = strings.TrimPrefix(handle, "@")
+	if handle == "" || leadingHandle(handle) != handle {
+		return ""
+	}
+	return strings.ToLower(handle)
+}
+
+func leadingHandle(s string) string {
+	end := 0
+	for end < len(s) && isHandleByte(s[end]) {
+		end++
+	}
+	if end > maxHandleLength {
+		return ""
+	}
+	return s[:end]
+}
+
+// leadingEmail matches a simple local@domain.tld address at the start of s.
+// A trailing dot or hyphen is left out, as in "ping @me@example.com."
+func leadingEmail(s string) string {
+	at := 0
+	for at < len(s) && isEmailLocalByte(s[at]) {
+		at++
+	}
+	if at == 0 || at == len(s) || s[at] != '@' {
+		return ""
+	}
+
+	end := at + 1
+	for end < len(s) && isDomainByte(s[end]) {
+		end++
+	}
+	for end > at+1 && (s[end-1] == '.' || s[end-1] == '-') {
+		end--
+	}
+
+	domain := s[at+1 : end]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.Contains(domain, "..") {
+		return ""
+	}
+	return s[:end]
+}
+
+func isMentionBoundary(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '@'
+}
+
+func isHandleByte(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || b == '_'
+}
+
+func isEmailLocalByte(b byte) bool {
+	return isHandleByte(b) || strings.IndexByte(".+-%", b) >= 0
+}
+
+func isDomainByte(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || b == '.' || b == '-'
+}
@@ -0,0 +1,112 @@
+package chirptext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Mention
+	}{
+		{
+			name: "No Mentions",
+			body: "just a chirp",
+			want: []Mention{},
+		},
+		{
+			name: "Handle",
+			body: "hi @Gopher!",
+			want: []Mention{{Handle: "gopher", Start: 3, End: 10}},
+		},
+		{
+			name: "Email",
+			body: "@Walt@Example.com.",
+			want: []Mention{{Email: "walt@example.com", Start: 0, End: 17}},
+		},
+		{
+			name: "Handle Followed By At Without Domain",
+			body: "@alice@home",
+			want: []Mention{{Handle: "alice", Start: 0, End: 6}},
+		},
+		{
+			name: "Plain Email Address Is Not A Mention",
+			body: "mail me at walt@example.com",
+			want: []Mention{},
+		},
+		{
+			name: "Offsets Count Characters",
+			body: "héllo 世界 @bob and @carol_2",
+			want: []Mention{
+				{Handle: "bob", Start: 9, End: 13},
+				{Handle: "carol_2", Start: 18, End: 26},
+			},
+		},
+		{
+			name: "Repeated Mentions Are Kept",
+			body: "@bob @bob",
+			want: []Mention{
+				{Handle: "bob", Start: 0, End: 4},
+				{Handle: "bob", Start: 5, End: 9},
+			},
+		},
+		{
+			name: "Bare At And Too Long Handle",
+			body: "@ @aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			want: []Mention{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Mentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mentions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeHandle(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle string
+		want   string
+	}{
+		{
+			name:   "Lowercased",
+			handle: "Gopher_42",
+			want:   "gopher_42",
+		},
+		{
+			name:   "Leading At",
+			handle: "@gopher",
+			want:   "gopher",
+		},
+		{
+			name:   "Empty",
+			handle: "",
+			want:   "",
+		},
+		{
+			name:   "Punctuation",
+			handle: "go.pher",
+			want:   "",
+		},
+		{
+			name:   "Non ASCII",
+			handle: "gøpher",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeHandle(tt.handle); got != tt.want {
+				t.Errorf("NormalizeHandle(%q) = %q, want %q", tt.handle, got, tt.want)
+			}
+		})
+	}
+}
@@ -7,25 +7,95 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const chirpHasReplies = `-- name: ChirpHasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE parent_chirp_id = $1
+)
+`
+
+// Counts replies in any state, scheduled and held ones included, so that a
+// chirp they're waiting to reply to is tombstoned rather than deleted.
+func (q *Queries) ChirpHasReplies(ctx context.Context, parentChirpID uuid.NullUUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, chirpHasReplies, parentChirpID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const createChirp = `-- name: CreateChirp :one
 INSERT INTO
-    chirps (id, created_at, updated_at, body, user_id)
+    chirps (id, created_at, updated_at, published_at, body, user_id, parent_chirp_id, quoted_chirp_id, status, publish_at, visibility, content_warning, sensitive, expires_at)
 VALUES
-    (gen_random_uuid (), NOW (), NOW (), $1, $2)
-RETURNING id, created_at, updated_at, body, user_id
+    (gen_random_uuid (), NOW (), NOW (), NOW (), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body           string
+	UserID         uuid.UUID
+	ParentChirpID  uuid.NullUUID
+	QuotedChirpID  uuid.NullUUID
+	Status         string
+	PublishAt      sql.NullTime
+	Visibility     string
+	ContentWarning string
+	Sensitive      bool
+	ExpiresAt      sql.NullTime
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.ParentChirpID, arg.QuotedChirpID, arg.Status, arg.PublishAt, arg.Visibility, arg.ContentWarning, arg.Sensitive, arg.ExpiresAt)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuotedChirpID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.ReactionCounts,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ExpiresAt,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO
+    chirps (id, created_at, updated_at, published_at, body, user_id, rechirp_of_id)
+VALUES
+    (gen_random_uuid (), NOW (), NOW (), NOW (), '', $1, $2)
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL DO NOTHING
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at
+`
+
+type CreateRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.UserID, arg.RechirpOfID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -33,10 +103,83 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuotedChirpID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.ReactionCounts,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ExpiresAt,
+		&i.PublishedAt,
 	)
 	return i, err
 }
 
+const decrementChirpQuoteCount = `-- name: DecrementChirpQuoteCount :exec
+UPDATE chirps
+SET quote_count = quote_count - 1
+WHERE id = $1
+`
+
+func (q *Queries) DecrementChirpQuoteCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementChirpQuoteCount, id)
+	return err
+}
+
+const decrementChirpReactionCount = `-- name: DecrementChirpReactionCount :exec
+UPDATE chirps
+SET reaction_counts = CASE
+        WHEN (reaction_counts ->> $1::text)::int > 1 THEN jsonb_set(
+            reaction_counts,
+            ARRAY[$1::text],
+            to_jsonb((reaction_counts ->> $1::text)::int - 1)
+        )
+        ELSE reaction_counts - $1::text
+    END
+WHERE id = $2
+`
+
+type DecrementChirpReactionCountParams struct {
+	Reaction string
+	ID       uuid.UUID
+}
+
+func (q *Queries) DecrementChirpReactionCount(ctx context.Context, arg DecrementChirpReactionCountParams) error {
+	_, err := q.db.ExecContext(ctx, decrementChirpReactionCount, arg.Reaction, arg.ID)
+	return err
+}
+
+const decrementChirpRechirpCount = `-- name: DecrementChirpRechirpCount :exec
+UPDATE chirps
+SET rechirp_count = rechirp_count - 1
+WHERE id = $1
+`
+
+func (q *Queries) DecrementChirpRechirpCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementChirpRechirpCount, id)
+	return err
+}
+
+const decrementChirpReplyCount = `-- name: DecrementChirpReplyCount :exec
+UPDATE chirps
+SET reply_count = reply_count - 1
+WHERE id = $1
+`
+
+func (q *Queries) DecrementChirpReplyCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementChirpReplyCount, id)
+	return err
+}
+
 const deleteChirpByID = `-- name: DeleteChirpByID :exec
 DELETE FROM chirps
 WHERE id = $1
@@ -47,13 +190,258 @@ func (q *Queries) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteRechirp = `-- name: DeleteRechirp :one
+DELETE FROM chirps
+WHERE user_id = $1 AND rechirp_of_id = $2
+RETURNING id
+`
+
+type DeleteRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, deleteRechirp, arg.UserID, arg.RechirpOfID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteRechirpsOf = `-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of_id = $1
+`
+
+func (q *Queries) DeleteRechirpsOf(ctx context.Context, rechirpOfID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirpsOf, rechirpOfID)
+	return err
+}
+
+const deleteUnrepliedTombstone = `-- name: DeleteUnrepliedTombstone :one
+DELETE FROM chirps
+WHERE id = $1
+    AND tombstoned_at IS NOT NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM chirps replies
+        WHERE replies.parent_chirp_id = chirps.id
+    )
+RETURNING parent_chirp_id
+`
+
+// Deletes a tombstone that nothing replies to any more, returning its
+// parent so the caller can check that in turn.
+func (q *Queries) DeleteUnrepliedTombstone(ctx context.Context, id uuid.UUID) (uuid.NullUUID, error) {
+	row := q.db.QueryRowContext(ctx, deleteUnrepliedTombstone, id)
+	var parent_chirp_id uuid.NullUUID
+	err := row.Scan(&parent_chirp_id)
+	return parent_chirp_id, err
+}
+
+const detachQuotedChirp = `-- name: DetachQuotedChirp :exec
+UPDATE chirps
+SET quoted_chirp_id = NULL, updated_at = NOW ()
+WHERE id = $1
+`
+
+func (q *Queries) DetachQuotedChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, detachQuotedChirp, id)
+	return err
+}
+
+const getAutoDeleteChirps = `-- name: GetAutoDeleteChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_chirp_id, chirps.reply_count, chirps.tombstoned_at, chirps.rechirp_of_id, chirps.quoted_chirp_id, chirps.rechirp_count, chirps.quote_count, chirps.reaction_counts, chirps.status, chirps.publish_at, chirps.deleted_at, chirps.visibility, chirps.content_warning, chirps.sensitive, chirps.expires_at, chirps.published_at FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE users.auto_delete_after_days IS NOT NULL
+    AND chirps.published_at < NOW () - make_interval(days => users.auto_delete_after_days)
+    AND chirps.status IN ('published', 'hidden')
+    AND chirps.deleted_at IS NULL
+    AND chirps.tombstoned_at IS NULL
+ORDER BY chirps.published_at
+LIMIT $1
+FOR UPDATE OF chirps SKIP LOCKED
+`
+
+// Chirps past their author's auto_delete_after_days. Scheduled and held
+// chirps haven't been out yet, and trashed ones are left to the trash purge.
+func (q *Queries) GetAutoDeleteChirps(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAutoDeleteChirps, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE
+    ancestors (id, parent_chirp_id, depth) AS (
+        SELECT parent.id, parent.parent_chirp_id, 1
+        FROM chirps parent
+        WHERE parent.id = (SELECT child.parent_chirp_id FROM chirps child WHERE child.id = $1)
+        UNION ALL
+        SELECT parent.id, parent.parent_chirp_id, ancestors.depth + 1
+        FROM chirps parent
+        JOIN ancestors ON parent.id = ancestors.parent_chirp_id
+        WHERE ancestors.depth < $2::int
+    )
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_chirp_id, chirps.reply_count, chirps.tombstoned_at, chirps.rechirp_of_id, chirps.quoted_chirp_id, chirps.rechirp_count, chirps.quote_count, chirps.reaction_counts, chirps.status, chirps.publish_at, chirps.deleted_at, chirps.visibility, chirps.content_warning, chirps.sensitive, chirps.expires_at, chirps.published_at, chirp_visible_to(chirps, $3::uuid) AS visible
+FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+ORDER BY ancestors.depth DESC
+`
+
+type GetChirpAncestorsParams struct {
+	ChirpID  uuid.UUID
+	MaxDepth int32
+	ViewerID uuid.NullUUID
+}
+
+type GetChirpAncestorsRow struct {
+	Chirp   Chirp
+	Visible bool
+}
+
+// Ancestors include trashed chirps, shown as placeholders, so a thread
+// keeps its shape. So do ones the viewer can't read, flagged as not
+// visible, so the chain always reaches the real root.
+func (q *Queries) GetChirpAncestors(ctx context.Context, arg GetChirpAncestorsParams) ([]GetChirpAncestorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, arg.ChirpID, arg.MaxDepth, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpAncestorsRow
+	for rows.Next() {
+		var i GetChirpAncestorsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.ParentChirpID,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.TombstonedAt,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuotedChirpID,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.QuoteCount,
+			&i.Chirp.ReactionCounts,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.PublishedAt,
+			&i.Visible,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
+WHERE id = $1
+    AND deleted_at IS NULL
+    AND chirp_visible_to(chirps, $2::uuid)
+`
+
+type GetChirpByIDParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpByID(ctx context.Context, arg GetChirpByIDParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByID, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuotedChirpID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.ReactionCounts,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ExpiresAt,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const getChirpByIDForUpdate = `-- name: GetChirpByIDForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
 WHERE id = $1
+    AND (expires_at IS NULL OR expires_at > NOW ())
+FOR UPDATE
 `
 
-func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirpByID, id)
+// Unlike GetChirpByID this also returns trashed chirps, so they can be
+// restored. Callers changing a chirp in place check deleted_at themselves.
+func (q *Queries) GetChirpByIDForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByIDForUpdate, id)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -61,17 +449,191 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuotedChirpID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.ReactionCounts,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ExpiresAt,
+		&i.PublishedAt,
 	)
 	return i, err
 }
 
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE
+    descendants (id, depth) AS (
+        SELECT child.id, 1
+        FROM chirps child
+        WHERE child.parent_chirp_id = $1
+        UNION ALL
+        SELECT child.id, descendants.depth + 1
+        FROM chirps child
+        JOIN descendants ON child.parent_chirp_id = descendants.id
+        WHERE descendants.depth < $2::int
+    )
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_chirp_id, chirps.reply_count, chirps.tombstoned_at, chirps.rechirp_of_id, chirps.quoted_chirp_id, chirps.rechirp_count, chirps.quote_count, chirps.reaction_counts, chirps.status, chirps.publish_at, chirps.deleted_at, chirps.visibility, chirps.content_warning, chirps.sensitive, chirps.expires_at, chirps.published_at, descendants.depth::int AS depth
+FROM chirps
+JOIN descendants ON chirps.id = descendants.id
+WHERE chirps.status = 'published'
+    AND (chirps.deleted_at IS NULL OR chirps.reply_count > 0)
+    AND chirp_listed_for(chirps, $3::uuid)
+    AND ($4::timestamp IS NULL
+        OR (chirps.published_at, chirps.id) > ($4::timestamp, $5::uuid))
+ORDER BY chirps.published_at ASC, chirps.id ASC
+LIMIT $6
+`
+
+type GetChirpDescendantsParams struct {
+	ChirpID         uuid.UUID
+	MaxDepth        int32
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type GetChirpDescendantsRow struct {
+	Chirp Chirp
+	Depth int32
+}
+
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]GetChirpDescendantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants, arg.ChirpID, arg.MaxDepth, arg.ViewerID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpDescendantsRow
+	for rows.Next() {
+		var i GetChirpDescendantsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.ParentChirpID,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.TombstonedAt,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuotedChirpID,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.QuoteCount,
+			&i.Chirp.ReactionCounts,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.PublishedAt,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at ASC
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
+WHERE tombstoned_at IS NULL
+    AND deleted_at IS NULL
+    AND status = 'published'
+    AND chirp_listed_for(chirps, $1::uuid)
+    AND ($2::timestamp IS NULL
+        OR (published_at, id) > ($2::timestamp, $3::uuid))
+ORDER BY published_at ASC, id ASC
+LIMIT $4
+`
+
+type GetChirpsParams struct {
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetChirps(ctx context.Context, arg GetChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps, arg.ViewerID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
+WHERE id = ANY ($1::uuid[])
+    AND deleted_at IS NULL
+    AND chirp_visible_to(chirps, $2::uuid)
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+type GetChirpsByIDsParams struct {
+	IDs      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.IDs), arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +647,23 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -100,14 +679,97 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpsByUserID = `-- name: GetChirpsByUserID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
+WHERE user_id = $1
+    AND EXISTS (SELECT 1 FROM users WHERE users.id = chirps.user_id)
+    AND tombstoned_at IS NULL
+    AND deleted_at IS NULL
+    AND status = 'published'
+    AND chirp_listed_for(chirps, $2::uuid)
+    AND ($3::timestamp IS NULL
+        OR (published_at, id) > ($3::timestamp, $4::uuid))
+ORDER BY published_at ASC, id ASC
+LIMIT $5
+`
+
+type GetChirpsByUserIDParams struct {
+	UserID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetChirpsByUserID(ctx context.Context, arg GetChirpsByUserIDParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUserID, arg.UserID, arg.ViewerID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByUserIDDesc = `-- name: GetChirpsByUserIDDesc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
 WHERE user_id = $1
     AND EXISTS (SELECT 1 FROM users WHERE users.id = chirps.user_id)
-ORDER BY created_at ASC
+    AND tombstoned_at IS NULL
+    AND deleted_at IS NULL
+    AND status = 'published'
+    AND chirp_listed_for(chirps, $2::uuid)
+    AND ($3::timestamp IS NULL
+        OR (published_at, id) < ($3::timestamp, $4::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT $5
 `
 
-func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUserID, userID)
+type GetChirpsByUserIDDescParams struct {
+	UserID          uuid.UUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetChirpsByUserIDDesc(ctx context.Context, arg GetChirpsByUserIDDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUserIDDesc, arg.UserID, arg.ViewerID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -121,6 +783,23 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -134,3 +813,965 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 	}
 	return items, nil
 }
+
+const getChirpsDesc = `-- name: GetChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
+WHERE tombstoned_at IS NULL
+    AND deleted_at IS NULL
+    AND status = 'published'
+    AND chirp_listed_for(chirps, $1::uuid)
+    AND ($2::timestamp IS NULL
+        OR (published_at, id) < ($2::timestamp, $3::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT $4
+`
+
+type GetChirpsDescParams struct {
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetChirpsDesc(ctx context.Context, arg GetChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsDesc, arg.ViewerID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsToPurge = `-- name: GetChirpsToPurge :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
+WHERE deleted_at < $1 AND tombstoned_at IS NULL
+ORDER BY deleted_at
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+type GetChirpsToPurgeParams struct {
+	DeletedAt sql.NullTime
+	Limit     int32
+}
+
+func (q *Queries) GetChirpsToPurge(ctx context.Context, arg GetChirpsToPurgeParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsToPurge, arg.DeletedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExpiredChirps = `-- name: GetExpiredChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
+WHERE expires_at <= NOW () AND tombstoned_at IS NULL
+ORDER BY expires_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) GetExpiredChirps(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getExpiredChirps, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHeldChirps = `-- name: GetHeldChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
+WHERE status = 'held'
+    AND deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > NOW ())
+    AND ($1::timestamp IS NULL
+        OR (created_at, id) > ($1::timestamp, $2::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $3
+`
+
+type GetHeldChirpsParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetHeldChirps(ctx context.Context, arg GetHeldChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getHeldChirps, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScheduledChirpsByUserID = `-- name: GetScheduledChirpsByUserID :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
+WHERE user_id = $1
+    AND status = 'scheduled'
+    AND deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > NOW ())
+    AND ($2::timestamp IS NULL
+        OR (publish_at, id) > ($2::timestamp, $3::uuid))
+ORDER BY publish_at ASC, id ASC
+LIMIT $4
+`
+
+type GetScheduledChirpsByUserIDParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetScheduledChirpsByUserID(ctx context.Context, arg GetScheduledChirpsByUserIDParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledChirpsByUserID, arg.UserID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrashedChirpsByUserID = `-- name: GetTrashedChirpsByUserID :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at FROM chirps
+WHERE user_id = $1
+    AND deleted_at IS NOT NULL
+    AND tombstoned_at IS NULL
+    AND (expires_at IS NULL OR expires_at > NOW ())
+    AND ($2::timestamp IS NULL
+        OR (deleted_at, id) < ($2::timestamp, $3::uuid))
+ORDER BY deleted_at DESC, id DESC
+LIMIT $4
+`
+
+type GetTrashedChirpsByUserIDParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetTrashedChirpsByUserID(ctx context.Context, arg GetTrashedChirpsByUserIDParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getTrashedChirpsByUserID, arg.UserID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hideChirp = `-- name: HideChirp :exec
+UPDATE chirps
+SET status = 'hidden'
+WHERE id = $1
+`
+
+func (q *Queries) HideChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, hideChirp, id)
+	return err
+}
+
+const incrementChirpQuoteCount = `-- name: IncrementChirpQuoteCount :exec
+UPDATE chirps
+SET quote_count = quote_count + 1
+WHERE id = $1
+`
+
+func (q *Queries) IncrementChirpQuoteCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementChirpQuoteCount, id)
+	return err
+}
+
+const incrementChirpReactionCount = `-- name: IncrementChirpReactionCount :exec
+UPDATE chirps
+SET reaction_counts = jsonb_set(
+        reaction_counts,
+        ARRAY[$1::text],
+        to_jsonb(COALESCE((reaction_counts ->> $1::text)::int, 0) + 1)
+    )
+WHERE id = $2
+`
+
+type IncrementChirpReactionCountParams struct {
+	Reaction string
+	ID       uuid.UUID
+}
+
+func (q *Queries) IncrementChirpReactionCount(ctx context.Context, arg IncrementChirpReactionCountParams) error {
+	_, err := q.db.ExecContext(ctx, incrementChirpReactionCount, arg.Reaction, arg.ID)
+	return err
+}
+
+const incrementChirpRechirpCount = `-- name: IncrementChirpRechirpCount :exec
+UPDATE chirps
+SET rechirp_count = rechirp_count + 1
+WHERE id = $1
+`
+
+func (q *Queries) IncrementChirpRechirpCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementChirpRechirpCount, id)
+	return err
+}
+
+const incrementChirpReplyCount = `-- name: IncrementChirpReplyCount :exec
+UPDATE chirps
+SET reply_count = reply_count + 1
+WHERE id = $1
+`
+
+func (q *Queries) IncrementChirpReplyCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementChirpReplyCount, id)
+	return err
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps
+SET status = 'published', published_at = NOW (), updated_at = NOW ()
+WHERE id IN (
+        SELECT id FROM chirps
+        WHERE status = 'scheduled' AND publish_at <= NOW () AND deleted_at IS NULL
+            AND (expires_at IS NULL OR expires_at > NOW ())
+        ORDER BY publish_at
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at
+`
+
+func (q *Queries) PublishDueChirps(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.TombstonedAt,
+			&i.RechirpOfID,
+			&i.QuotedChirpID,
+			&i.RechirpCount,
+			&i.QuoteCount,
+			&i.ReactionCounts,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ExpiresAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishHeldChirp = `-- name: PublishHeldChirp :one
+UPDATE chirps
+SET updated_at = NOW (),
+    status = CASE WHEN publish_at > NOW () THEN 'scheduled' ELSE 'published' END,
+    published_at = CASE WHEN publish_at > NOW () THEN published_at ELSE NOW () END
+WHERE id = $1 AND status = 'held' AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at
+`
+
+func (q *Queries) PublishHeldChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, publishHeldChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuotedChirpID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.ReactionCounts,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ExpiresAt,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const rescheduleChirp = `-- name: RescheduleChirp :one
+UPDATE chirps
+SET updated_at = NOW (), publish_at = $2
+WHERE id = $1 AND status = 'scheduled' AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at
+`
+
+type RescheduleChirpParams struct {
+	ID        uuid.UUID
+	PublishAt sql.NullTime
+}
+
+func (q *Queries) RescheduleChirp(ctx context.Context, arg RescheduleChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, rescheduleChirp, arg.ID, arg.PublishAt)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuotedChirpID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.ReactionCounts,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ExpiresAt,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at
+`
+
+func (q *Queries) RestoreChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuotedChirpID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.ReactionCounts,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ExpiresAt,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_chirp_id, chirps.reply_count, chirps.tombstoned_at, chirps.rechirp_of_id, chirps.quoted_chirp_id, chirps.rechirp_count, chirps.quote_count, chirps.reaction_counts, chirps.status, chirps.publish_at, chirps.deleted_at, chirps.visibility, chirps.content_warning, chirps.sensitive, chirps.expires_at, chirps.published_at,
+    ts_rank_cd(chirps.search_vector, query)::real AS rank,
+    chirp_search_snippet(chirps.body, query) AS snippet
+FROM chirps, to_tsquery('english', $1) query
+WHERE chirps.search_vector @@ query
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirp_listed_for(chirps, $2::uuid)
+    AND ($3::uuid IS NULL OR chirps.user_id = $3::uuid)
+    AND ($4::real IS NULL
+        OR (ts_rank_cd(chirps.search_vector, query), chirps.published_at, chirps.id)
+            < ($4::real, $5::timestamp, $6::uuid))
+ORDER BY rank DESC, chirps.published_at DESC, chirps.id DESC
+LIMIT $7
+`
+
+type SearchChirpsParams struct {
+	Query           string
+	ViewerID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	CursorRank      sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type SearchChirpsRow struct {
+	Chirp   Chirp
+	Rank    float32
+	Snippet string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps, arg.Query, arg.ViewerID, arg.AuthorID, arg.CursorRank, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.ParentChirpID,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.TombstonedAt,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuotedChirpID,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.QuoteCount,
+			&i.Chirp.ReactionCounts,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.PublishedAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirpsByDate = `-- name: SearchChirpsByDate :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_chirp_id, chirps.reply_count, chirps.tombstoned_at, chirps.rechirp_of_id, chirps.quoted_chirp_id, chirps.rechirp_count, chirps.quote_count, chirps.reaction_counts, chirps.status, chirps.publish_at, chirps.deleted_at, chirps.visibility, chirps.content_warning, chirps.sensitive, chirps.expires_at, chirps.published_at,
+    ts_rank_cd(chirps.search_vector, query)::real AS rank,
+    chirp_search_snippet(chirps.body, query) AS snippet
+FROM chirps, to_tsquery('english', $1) query
+WHERE chirps.search_vector @@ query
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirp_listed_for(chirps, $2::uuid)
+    AND ($3::uuid IS NULL OR chirps.user_id = $3::uuid)
+    AND ($4::timestamp IS NULL
+        OR (chirps.published_at, chirps.id) > ($4::timestamp, $5::uuid))
+ORDER BY chirps.published_at ASC, chirps.id ASC
+LIMIT $6
+`
+
+type SearchChirpsByDateParams struct {
+	Query           string
+	ViewerID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type SearchChirpsByDateRow struct {
+	Chirp   Chirp
+	Rank    float32
+	Snippet string
+}
+
+func (q *Queries) SearchChirpsByDate(ctx context.Context, arg SearchChirpsByDateParams) ([]SearchChirpsByDateRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirpsByDate, arg.Query, arg.ViewerID, arg.AuthorID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsByDateRow
+	for rows.Next() {
+		var i SearchChirpsByDateRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.ParentChirpID,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.TombstonedAt,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuotedChirpID,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.QuoteCount,
+			&i.Chirp.ReactionCounts,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.PublishedAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirpsByDateDesc = `-- name: SearchChirpsByDateDesc :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.parent_chirp_id, chirps.reply_count, chirps.tombstoned_at, chirps.rechirp_of_id, chirps.quoted_chirp_id, chirps.rechirp_count, chirps.quote_count, chirps.reaction_counts, chirps.status, chirps.publish_at, chirps.deleted_at, chirps.visibility, chirps.content_warning, chirps.sensitive, chirps.expires_at, chirps.published_at,
+    ts_rank_cd(chirps.search_vector, query)::real AS rank,
+    chirp_search_snippet(chirps.body, query) AS snippet
+FROM chirps, to_tsquery('english', $1) query
+WHERE chirps.search_vector @@ query
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirp_listed_for(chirps, $2::uuid)
+    AND ($3::uuid IS NULL OR chirps.user_id = $3::uuid)
+    AND ($4::timestamp IS NULL
+        OR (chirps.published_at, chirps.id) < ($4::timestamp, $5::uuid))
+ORDER BY chirps.published_at DESC, chirps.id DESC
+LIMIT $6
+`
+
+type SearchChirpsByDateDescParams struct {
+	Query           string
+	ViewerID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type SearchChirpsByDateDescRow struct {
+	Chirp   Chirp
+	Rank    float32
+	Snippet string
+}
+
+func (q *Queries) SearchChirpsByDateDesc(ctx context.Context, arg SearchChirpsByDateDescParams) ([]SearchChirpsByDateDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirpsByDateDesc, arg.Query, arg.ViewerID, arg.AuthorID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsByDateDescRow
+	for rows.Next() {
+		var i SearchChirpsByDateDescRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.ParentChirpID,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.TombstonedAt,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuotedChirpID,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.QuoteCount,
+			&i.Chirp.ReactionCounts,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.ExpiresAt,
+			&i.Chirp.PublishedAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setChirpContentFlags = `-- name: SetChirpContentFlags :one
+UPDATE chirps
+SET content_warning = $2, sensitive = $3
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at
+`
+
+type SetChirpContentFlagsParams struct {
+	ID             uuid.UUID
+	ContentWarning string
+	Sensitive      bool
+}
+
+func (q *Queries) SetChirpContentFlags(ctx context.Context, arg SetChirpContentFlagsParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, setChirpContentFlags, arg.ID, arg.ContentWarning, arg.Sensitive)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuotedChirpID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.ReactionCounts,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ExpiresAt,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps
+SET updated_at = NOW (), body = '', tombstoned_at = NOW (), quoted_chirp_id = NULL, rechirp_count = 0, reaction_counts = '{}', expires_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
+
+const trashChirp = `-- name: TrashChirp :exec
+UPDATE chirps
+SET deleted_at = NOW ()
+WHERE id = $1
+`
+
+func (q *Queries) TrashChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, trashChirp, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET updated_at = NOW (), body = $2
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, search_vector, parent_chirp_id, reply_count, tombstoned_at, rechirp_of_id, quoted_chirp_id, rechirp_count, quote_count, reaction_counts, status, publish_at, deleted_at, visibility, content_warning, sensitive, expires_at, published_at
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.TombstonedAt,
+		&i.RechirpOfID,
+		&i.QuotedChirpID,
+		&i.RechirpCount,
+		&i.QuoteCount,
+		&i.ReactionCounts,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ExpiresAt,
+		&i.PublishedAt,
+	)
+	return i, err
+}
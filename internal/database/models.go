@@ -6,17 +6,195 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-type Chirp struct {
+type BannedWord struct {
+	Word      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Action    string
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	FolderID  uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type BookmarkFolder struct {
 	ID        uuid.UUID
+	UserID    uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Name      string
+}
+
+type Chirp struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Body           string
+	UserID         uuid.UUID
+	SearchVector   interface{}
+	ParentChirpID  uuid.NullUUID
+	ReplyCount     int32
+	TombstonedAt   sql.NullTime
+	RechirpOfID    uuid.NullUUID
+	QuotedChirpID  uuid.NullUUID
+	RechirpCount   int32
+	QuoteCount     int32
+	ReactionCounts json.RawMessage
+	Status         string
+	PublishAt      sql.NullTime
+	DeletedAt      sql.NullTime
+	Visibility     string
+	ContentWarning string
+	Sensitive      bool
+	ExpiresAt      sql.NullTime
+	PublishedAt    time.Time
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type ChirpMedium struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+	AltText  string
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpReaction struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Reaction  string
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ChirpID   uuid.UUID
 	Body      string
+}
+
+type Draft struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UserID         uuid.UUID
+	Body           string
+	InReplyTo      uuid.NullUUID
+	QuotedChirpID  uuid.NullUUID
+	Media          json.RawMessage
+	PublishAt      sql.NullTime
+	Version        int32
+	DeletedAt      sql.NullTime
+	Visibility     string
+	ContentWarning string
+	Sensitive      bool
+	ChangeSeq      int64
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Medium struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UserID               uuid.UUID
+	ContentType          string
+	SizeBytes            int64
+	Width                int32
+	Height               int32
+	StorageKey           string
+	ThumbnailKey         string
+	ThumbnailContentType string
+}
+
+type ModerationAction struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	ModeratorID   uuid.NullUUID
+	ReportID      uuid.NullUUID
+	Action        string
+	Reason        string
+	TargetUserID  uuid.UUID
+	TargetChirpID uuid.NullUUID
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type MutedWord struct {
+	ID        uuid.UUID
 	UserID    uuid.UUID
+	CreatedAt time.Time
+	Phrase    string
+	ExpiresAt sql.NullTime
+}
+
+type PinnedChirp struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	Position  int32
+	CreatedAt time.Time
+}
+
+type PolkaEvent struct {
+	ID         string
+	UserID     uuid.UUID
+	Event      string
+	ReceivedAt time.Time
+}
+
+type Poll struct {
+	ChirpID     uuid.UUID
+	CreatedAt   time.Time
+	ClosesAt    time.Time
+	FinalizedAt sql.NullTime
+	VoteCount   int32
+}
+
+type PollOption struct {
+	ChirpID   uuid.UUID
+	Position  int32
+	Text      string
+	VoteCount int32
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Position  int32
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +206,57 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type Report struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	ReporterID       uuid.UUID
+	ReportedUserID   uuid.UUID
+	ChirpID          uuid.NullUUID
+	ChirpBody        sql.NullString
+	Reason           string
+	Details          string
+	Status           string
+	ClaimedBy        uuid.NullUUID
+	ClaimedAt        sql.NullTime
+	Resolution       sql.NullString
+	ResolutionReason sql.NullString
+	ResolvedAt       sql.NullTime
+}
+
+type Subscription struct {
+	ID                 uuid.UUID
+	UserID             uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Plan               string
+	Status             string
+	CurrentPeriodStart time.Time
+	CurrentPeriodEnd   time.Time
+	TrialEndsAt        sql.NullTime
+	GracePeriodEndsAt  time.Time
+	CanceledAt         sql.NullTime
+	EndedAt            sql.NullTime
+}
+
+type TrendingTag struct {
+	Tag        string
+	Score      float64
+	ChirpCount int32
+	ComputedAt time.Time
+}
+
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Email               string
+	HashedPassword      string
+	Handle              sql.NullString
+	IsAdmin             bool
+	FollowerCount       int32
+	FollowingCount      int32
+	SuspendedAt         sql.NullTime
+	ExpandSensitive     bool
+	AutoDeleteAfterDays sql.NullInt32
 }
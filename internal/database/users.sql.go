@@ -7,25 +7,28 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
 INSERT INTO
-    users (id, created_at, updated_at, email, hashed_password)
+    users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES
-    (gen_random_uuid (), NOW (), NOW (), $1, $2)
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+    (gen_random_uuid (), NOW (), NOW (), $1, $2, $3)
+RETURNING id, created_at, updated_at, email, hashed_password, handle, is_admin, follower_count, following_count, suspended_at, expand_sensitive, auto_delete_after_days
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -33,7 +36,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.SuspendedAt,
+		&i.ExpandSensitive,
+		&i.AutoDeleteAfterDays,
 	)
 	return i, err
 }
@@ -48,7 +57,7 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, handle, is_admin, follower_count, following_count, suspended_at, expand_sensitive, auto_delete_after_days FROM users
 WHERE email = $1
 `
 
@@ -61,26 +70,173 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.SuspendedAt,
+		&i.ExpandSensitive,
+		&i.AutoDeleteAfterDays,
 	)
 	return i, err
 }
 
-const updateUser = `-- name: UpdateUser :one
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, handle, is_admin, follower_count, following_count, suspended_at, expand_sensitive, auto_delete_after_days FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Handle,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.SuspendedAt,
+		&i.ExpandSensitive,
+		&i.AutoDeleteAfterDays,
+	)
+	return i, err
+}
+
+const getUserByIDForUpdate = `-- name: GetUserByIDForUpdate :one
+SELECT id, created_at, updated_at, email, hashed_password, handle, is_admin, follower_count, following_count, suspended_at, expand_sensitive, auto_delete_after_days FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIDForUpdate, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Handle,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.SuspendedAt,
+		&i.ExpandSensitive,
+		&i.AutoDeleteAfterDays,
+	)
+	return i, err
+}
+
+const getUsersByHandlesOrEmails = `-- name: GetUsersByHandlesOrEmails :many
+SELECT id, created_at, updated_at, email, hashed_password, handle, is_admin, follower_count, following_count, suspended_at, expand_sensitive, auto_delete_after_days FROM users
+WHERE handle = ANY($1::text[])
+    OR (
+        LOWER(email) = ANY($2::text[])
+        AND EXISTS (
+            SELECT 1 FROM chirps
+            WHERE chirps.id = $3
+                AND chirp_visible_to(
+                    jsonb_populate_record(chirps, to_jsonb(chirps) || '{"status": "published"}'),
+                    users.id
+                )
+        )
+    )
+`
+
+type GetUsersByHandlesOrEmailsParams struct {
+	Handles []string
+	Emails  []string
+	ChirpID uuid.UUID
+}
+
+// An email only matches a user who'll be able to read the chirp once it's
+// published, not counting the mention itself, so mentions can't be used
+// to find out whose address it is.
+func (q *Queries) GetUsersByHandlesOrEmails(ctx context.Context, arg GetUsersByHandlesOrEmailsParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandlesOrEmails, pq.Array(arg.Handles), pq.Array(arg.Emails), arg.ChirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.Handle,
+			&i.IsAdmin,
+			&i.FollowerCount,
+			&i.FollowingCount,
+			&i.SuspendedAt,
+			&i.ExpandSensitive,
+			&i.AutoDeleteAfterDays,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isUserSuspended = `-- name: IsUserSuspended :one
+SELECT EXISTS(
+    SELECT 1 FROM users WHERE id = $1 AND suspended_at IS NOT NULL
+)
+`
+
+func (q *Queries) IsUserSuspended(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isUserSuspended, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const suspendUser = `-- name: SuspendUser :exec
 UPDATE users
-SET updated_at = NOW (), email = $2, hashed_password = $3
+SET suspended_at = COALESCE(suspended_at, NOW ()), updated_at = NOW ()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+`
+
+func (q *Queries) SuspendUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, suspendUser, id)
+	return err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET
+    updated_at = NOW (),
+    email = $1,
+    hashed_password = $2,
+    handle = COALESCE($3, handle)
+WHERE id = $4
+RETURNING id, created_at, updated_at, email, hashed_password, handle, is_admin, follower_count, following_count, suspended_at, expand_sensitive, auto_delete_after_days
 `
 
 type UpdateUserParams struct {
-	ID             uuid.UUID
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
+	ID             uuid.UUID
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.ID, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, updateUser, arg.Email, arg.HashedPassword, arg.Handle, arg.ID)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -88,20 +244,48 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.SuspendedAt,
+		&i.ExpandSensitive,
+		&i.AutoDeleteAfterDays,
 	)
 	return i, err
 }
 
-const upgradeUserToChirpyRed = `-- name: UpgradeUserToChirpyRed :exec
+const updateUserPreferences = `-- name: UpdateUserPreferences :one
 UPDATE users
-SET is_chirpy_red = TRUE
+SET expand_sensitive = $2, auto_delete_after_days = $3, updated_at = NOW ()
 WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, handle, is_admin, follower_count, following_count, suspended_at, expand_sensitive, auto_delete_after_days
 `
 
-func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, upgradeUserToChirpyRed, id)
-	return err
+type UpdateUserPreferencesParams struct {
+	ID                  uuid.UUID
+	ExpandSensitive     bool
+	AutoDeleteAfterDays sql.NullInt32
+}
+
+func (q *Queries) UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPreferences, arg.ID, arg.ExpandSensitive, arg.AutoDeleteAfterDays)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Handle,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.SuspendedAt,
+		&i.ExpandSensitive,
+		&i.AutoDeleteAfterDays,
+	)
+	return i, err
 }
 
 const userExists = `-- name: UserExists :one
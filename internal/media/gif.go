@@ -0,0 +1,68 @@
+package media
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// checkGIFFrames walks the blocks of a GIF file without decoding them, and
+// rejects animations with more frames, or more pixels across all frames,
+// than are safe to decode. A small file can hold thousands of frames, and
+// gif.DecodeAll keeps every one of them in memory. Anything it can't make
+// sense of is left for the decoder to reject.
+func checkGIFFrames(data []byte) error {
+	if len(data) < 13 {
+		return nil
+	}
+	i := 13
+	if data[10]&0x80 != 0 {
+		i += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames, pixels := 0, 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // extension
+			i = skipGIFSubBlocks(data, i+2)
+		case 0x2C: // image descriptor
+			if i+10 > len(data) {
+				return nil
+			}
+			width := int(binary.LittleEndian.Uint16(data[i+5 : i+7]))
+			height := int(binary.LittleEndian.Uint16(data[i+7 : i+9]))
+			frames++
+			pixels += width * height
+			if frames > maxGIFFrames {
+				return fmt.Errorf("GIF has more than %d frames", maxGIFFrames)
+			}
+			if pixels > maxGIFPixels {
+				return fmt.Errorf("GIF frames add up to more than %d pixels", maxGIFPixels)
+			}
+
+			packed := data[i+9]
+			i += 10
+			if packed&0x80 != 0 {
+				i += 3 << (packed&0x07 + 1)
+			}
+			// Skip the LZW minimum code size, then the image data.
+			i = skipGIFSubBlocks(data, i+1)
+		default: // trailer, or something the decoder will reject
+			return nil
+		}
+	}
+	return nil
+}
+
+// skipGIFSubBlocks returns the index just past the run of data sub-blocks
+// starting at i, which ends with an empty block.
+func skipGIFSubBlocks(data []byte, i int) int {
+	for i < len(data) {
+		n := int(data[i])
+		i++
+		if n == 0 {
+			break
+		}
+		i += n
+	}
+	return i
+}
@@ -0,0 +1,137 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	ThumbnailSize = 320
+	// maxPixels keeps a small file that decodes to a huge image from
+	// exhausting memory.
+	maxPixels = 40_000_000
+	// maxGIFFrames and maxGIFPixels do the same for animations, where every
+	// frame is decoded and held at once.
+	maxGIFFrames = 1000
+	maxGIFPixels = 100_000_000
+	jpegQuality  = 90
+)
+
+var ErrUnsupportedType = errors.New("unsupported media type")
+
+// Image is an upload that is safe to store and serve.
+type Image struct {
+	ContentType          string
+	Data                 []byte
+	Width                int
+	Height               int
+	Thumbnail            []byte
+	ThumbnailContentType string
+}
+
+// Process checks that data is a JPEG, PNG or GIF image, judged by its
+// content rather than its name, and re-encodes it. Re-encoding drops EXIF
+// and other metadata, such as GPS coordinates, that the original carried.
+// It also produces a thumbnail no larger than ThumbnailSize on either side.
+func Process(data []byte) (Image, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return Image{}, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("couldn't decode image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return Image{}, fmt.Errorf("image dimensions %dx%d are not allowed", config.Width, config.Height)
+	}
+
+	processed := Image{ContentType: contentType}
+	var frame image.Image
+	buf := bytes.Buffer{}
+
+	switch contentType {
+	case "image/jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("couldn't decode image: %w", err)
+		}
+		frame = orient(img, jpegOrientation(data))
+		err = jpeg.Encode(&buf, frame, &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return Image{}, fmt.Errorf("couldn't encode image: %w", err)
+		}
+	case "image/png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("couldn't decode image: %w", err)
+		}
+		frame = img
+		if err := png.Encode(&buf, frame); err != nil {
+			return Image{}, fmt.Errorf("couldn't encode image: %w", err)
+		}
+	case "image/gif":
+		if err := checkGIFFrames(data); err != nil {
+			return Image{}, err
+		}
+		// Decode every frame so animations survive re-encoding.
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("couldn't decode image: %w", err)
+		}
+		frame = anim.Image[0]
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			return Image{}, fmt.Errorf("couldn't encode image: %w", err)
+		}
+	}
+	processed.Data = buf.Bytes()
+	processed.Width = frame.Bounds().Dx()
+	processed.Height = frame.Bounds().Dy()
+
+	processed.Thumbnail, processed.ThumbnailContentType, err = thumbnail(frame, contentType)
+	if err != nil {
+		return Image{}, err
+	}
+
+	return processed, nil
+}
+
+// thumbnail scales img down to fit in a ThumbnailSize square. Photos stay
+// JPEG; everything else becomes PNG to keep transparency.
+func thumbnail(img image.Image, contentType string) ([]byte, string, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > ThumbnailSize || h > ThumbnailSize {
+		if w >= h {
+			w, h = ThumbnailSize, max(1, h*ThumbnailSize/w)
+		} else {
+			w, h = max(1, w*ThumbnailSize/h), ThumbnailSize
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+
+	buf := bytes.Buffer{}
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, "", fmt.Errorf("couldn't encode thumbnail: %w", err)
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, "", fmt.Errorf("couldn't encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), "image/png", nil
+}
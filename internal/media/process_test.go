@@ -0,0 +1,234 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestProcessPNG(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 640, 480))
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() unexpected error: %v", err)
+	}
+
+	got, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Process() unexpected error: %v", err)
+	}
+	if got.ContentType != "image/png" || got.Width != 640 || got.Height != 480 {
+		t.Errorf("Process() = %s %dx%d, want image/png 640x480", got.ContentType, got.Width, got.Height)
+	}
+
+	thumb, err := png.Decode(bytes.NewReader(got.Thumbnail))
+	if err != nil {
+		t.Fatalf("png.Decode() of thumbnail unexpected error: %v", err)
+	}
+	if size := thumb.Bounds().Size(); size != image.Pt(320, 240) {
+		t.Errorf("thumbnail size = %v, want (320,240)", size)
+	}
+}
+
+func TestProcessJPEGStripsEXIFAndKeepsOrientation(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("jpeg.Encode() unexpected error: %v", err)
+	}
+	data := withEXIFOrientation(buf.Bytes(), 6)
+
+	got, err := Process(data)
+	if err != nil {
+		t.Fatalf("Process() unexpected error: %v", err)
+	}
+	if got.Width != 20 || got.Height != 40 {
+		t.Errorf("Process() size = %dx%d, want 20x40", got.Width, got.Height)
+	}
+	if bytes.Contains(got.Data, []byte("Exif")) {
+		t.Errorf("Process() kept EXIF data")
+	}
+	if got.ThumbnailContentType != "image/jpeg" {
+		t.Errorf("thumbnail content type = %s, want image/jpeg", got.ThumbnailContentType)
+	}
+}
+
+func TestProcessAnimatedGIF(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	anim := &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 10, 10), palette),
+			image.NewPaletted(image.Rect(0, 0, 10, 10), palette),
+		},
+		Delay: []int{10, 10},
+	}
+	buf := bytes.Buffer{}
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("gif.EncodeAll() unexpected error: %v", err)
+	}
+
+	got, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Process() unexpected error: %v", err)
+	}
+	decoded, err := gif.DecodeAll(bytes.NewReader(got.Data))
+	if err != nil {
+		t.Fatalf("gif.DecodeAll() unexpected error: %v", err)
+	}
+	if len(decoded.Image) != 2 {
+		t.Errorf("Process() kept %d frames, want 2", len(decoded.Image))
+	}
+}
+
+func TestCheckGIFFrames(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		frames        int
+		wantErr       bool
+	}{
+		{
+			name:   "Within Limits",
+			width:  100,
+			height: 100,
+			frames: 100,
+		},
+		{
+			name:    "Too Many Frames",
+			width:   1,
+			height:  1,
+			frames:  maxGIFFrames + 1,
+			wantErr: true,
+		},
+		{
+			name:    "Too Many Pixels",
+			width:   6000,
+			height:  6000,
+			frames:  3,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkGIFFrames(rawGIF(tt.width, tt.height, tt.frames))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkGIFFrames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// rawGIF builds a GIF with the given number of frames, each filling a
+// width by height screen, and no pixel data to speak of.
+func rawGIF(width, height, frames int) []byte {
+	data := []byte("GIF89a")
+	data = binary.LittleEndian.AppendUint16(data, uint16(width))
+	data = binary.LittleEndian.AppendUint16(data, uint16(height))
+	data = append(data, 0x80, 0, 0, 0, 0, 0, 0xFF, 0xFF, 0xFF)
+	for range frames {
+		data = append(data, 0x2C, 0, 0, 0, 0)
+		data = binary.LittleEndian.AppendUint16(data, uint16(width))
+		data = binary.LittleEndian.AppendUint16(data, uint16(height))
+		data = append(data, 0, 2, 0)
+	}
+	return append(data, 0x3B)
+}
+
+func TestProcessRejectsUnsupportedTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "Text",
+			data: []byte("definitely not an image"),
+		},
+		{
+			name: "HTML Named Like An Image",
+			data: []byte("<html><script>alert(1)</script></html>"),
+		},
+		{
+			name: "PDF",
+			data: []byte("%PDF-1.7\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Process(tt.data)
+			if !errors.Is(err, ErrUnsupportedType) {
+				t.Errorf("Process() error = %v, want %v", err, ErrUnsupportedType)
+			}
+		})
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)), nil); err != nil {
+		t.Fatalf("jpeg.Encode() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{
+			name: "No EXIF",
+			data: buf.Bytes(),
+			want: 1,
+		},
+		{
+			name: "Rotated",
+			data: withEXIFOrientation(buf.Bytes(), 8),
+			want: 8,
+		},
+		{
+			name: "Out Of Range",
+			data: withEXIFOrientation(buf.Bytes(), 9),
+			want: 1,
+		},
+		{
+			name: "Truncated",
+			data: withEXIFOrientation(buf.Bytes(), 6)[:20],
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("jpegOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// withEXIFOrientation inserts a big-endian EXIF segment holding just an
+// orientation tag after the JPEG's start-of-image marker.
+func withEXIFOrientation(jpegData []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, app1...)
+	return append(out, jpegData[2:]...)
+}
@@ -0,0 +1,108 @@
+package mutedwords
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		want string
+	}{
+		{name: "Keyword", rule: "Spoilers", want: "spoilers"},
+		{name: "Phrase", rule: "  Game  of THRONES! ", want: "game of thrones"},
+		{name: "Accents", rule: "Café", want: "cafe"},
+		{name: "Hashtag", rule: "#GoLang", want: "#golang"},
+		{name: "Invalid Hashtag", rule: "#not a tag", want: ""},
+		{name: "Only Punctuation", rule: "?!", want: ""},
+		{name: "Empty", rule: "   ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.rule); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherMatch(t *testing.T) {
+	matcher := NewMatcher([]string{"spoilers", "game of thrones", "#golang", "cafe"})
+
+	tests := []struct {
+		name      string
+		text      string
+		wantRule  string
+		wantMatch bool
+	}{
+		{name: "No Match", text: "A quiet day", wantMatch: false},
+		{name: "Keyword", text: "No SPOILERS please", wantRule: "spoilers", wantMatch: true},
+		{name: "Keyword Inside Word", text: "spoilersport", wantMatch: false},
+		{name: "Keyword As Hashtag", text: "#spoilers ahead", wantRule: "spoilers", wantMatch: true},
+		{name: "Phrase", text: "Who watched Game of Thrones?", wantRule: "game of thrones", wantMatch: true},
+		{name: "Phrase Across Punctuation", text: "game, of... thrones", wantRule: "game of thrones", wantMatch: true},
+		{name: "Partial Phrase", text: "a game of chess", wantMatch: false},
+		{name: "Hashtag", text: "Learning #GoLang", wantRule: "#golang", wantMatch: true},
+		{name: "Hashtag Rule Ignores Plain Word", text: "golang is fun", wantMatch: false},
+		{name: "Accented Text", text: "Meet at the Café", wantRule: "cafe", wantMatch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := matcher.Match(tt.text)
+			if ok != tt.wantMatch || rule != tt.wantRule {
+				t.Errorf("Match(%q) = %q, %v, want %q, %v", tt.text, rule, ok, tt.wantRule, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestEmptyMatcher(t *testing.T) {
+	if rule, ok := NewMatcher(nil).Match("anything #at all"); ok {
+		t.Errorf("empty matcher matched %q", rule)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	type chirp struct {
+		body      string
+		mutedWord string
+	}
+	matcher := NewMatcher([]string{"spoilers"})
+	texts := func(c chirp) []string { return []string{c.body} }
+	hide := func(c *chirp, rule string) {
+		c.body = ""
+		c.mutedWord = rule
+	}
+
+	tests := []struct {
+		name     string
+		collapse bool
+		want     []chirp
+	}{
+		{
+			name: "Hide Drops Matches",
+			want: []chirp{{body: "hello"}},
+		},
+		{
+			name:     "Collapse Blanks Matches",
+			collapse: true,
+			want:     []chirp{{body: "hello"}, {mutedWord: "spoilers"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chirps := []chirp{{body: "hello"}, {body: "big spoilers ahead"}}
+			got := Filter(matcher, chirps, texts, tt.collapse, hide)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Filter() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Filter()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor marks a position in a listing ordered by (created_at, id), or by
+// (rank, created_at, id) for ranked search results.
+// Clients receive it as an opaque string and send it back unchanged.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	Rank      float32   `json:"r,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("couldn't decode cursor: %w", err)
+	}
+
+	cursor := Cursor{}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return Cursor{}, fmt.Errorf("couldn't parse cursor: %w", err)
+	}
+	if cursor.CreatedAt.IsZero() || cursor.ID == uuid.Nil {
+		return Cursor{}, errors.New("cursor is incomplete")
+	}
+
+	return cursor, nil
+}
+
+// SyncCursor marks how far a client has synced a listing ordered by a change
+// counter. Since is when the client was last caught up, which tells us
+// whether changes it hasn't seen yet may have been purged.
+type SyncCursor struct {
+	Seq   int64     `json:"s"`
+	Since time.Time `json:"t"`
+}
+
+func (c SyncCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeSyncCursor(s string) (SyncCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return SyncCursor{}, fmt.Errorf("couldn't decode cursor: %w", err)
+	}
+
+	// Unknown fields mean a cursor from some other listing.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	cursor := SyncCursor{}
+	if err := decoder.Decode(&cursor); err != nil {
+		return SyncCursor{}, fmt.Errorf("couldn't parse cursor: %w", err)
+	}
+	if cursor.Since.IsZero() || cursor.Seq < 0 {
+		return SyncCursor{}, errors.New("cursor is incomplete")
+	}
+
+	return cursor, nil
+}
+
+// ParseLimit parses a page size, falling back to defaultLimit when s is
+// empty and capping the result at maxLimit.
+func ParseLimit(s string, defaultLimit, maxLimit int) (int, error) {
+	if s == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't parse limit: %w", err)
+	}
+	if limit < 1 {
+		return 0, errors.New("limit must be positive")
+	}
+
+	return min(limit, maxLimit), nil
+}
@@ -0,0 +1,174 @@
+package pagination
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{
+		CreatedAt: time.Date(2024, 12, 18, 10, 30, 15, 123456000, time.UTC),
+		ID:        uuid.New(),
+		Rank:      0.0607927,
+	}
+
+	got, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor() unexpected error: %v", err)
+	}
+	if !got.CreatedAt.Equal(cursor.CreatedAt) || got.ID != cursor.ID || got.Rank != cursor.Rank {
+		t.Errorf("DecodeCursor() = %v, want %v", got, cursor)
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []struct {
+		name        string
+		cursor      string
+		errorString string
+	}{
+		{
+			name:        "Not Base64",
+			cursor:      "not a cursor!",
+			errorString: "couldn't decode cursor",
+		},
+		{
+			name:        "Not JSON",
+			cursor:      "bm90IGpzb24",
+			errorString: "couldn't parse cursor",
+		},
+		{
+			name:        "Missing ID",
+			cursor:      Cursor{CreatedAt: time.Now()}.Encode(),
+			errorString: "cursor is incomplete",
+		},
+		{
+			name:        "Missing Timestamp",
+			cursor:      Cursor{ID: uuid.New()}.Encode(),
+			errorString: "cursor is incomplete",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCursor(tt.cursor)
+			if err == nil {
+				t.Fatalf("DecodeCursor() expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorString) {
+				t.Errorf("DecodeCursor() error = %v, expected substring %v", err, tt.errorString)
+			}
+		})
+	}
+}
+
+func TestSyncCursorRoundTrip(t *testing.T) {
+	cursor := SyncCursor{
+		Seq:   42,
+		Since: time.Date(2024, 12, 18, 10, 30, 15, 123456000, time.UTC),
+	}
+
+	got, err := DecodeSyncCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodeSyncCursor() unexpected error: %v", err)
+	}
+	if got.Seq != cursor.Seq || !got.Since.Equal(cursor.Since) {
+		t.Errorf("DecodeSyncCursor() = %v, want %v", got, cursor)
+	}
+}
+
+func TestDecodeSyncCursor(t *testing.T) {
+	tests := []struct {
+		name        string
+		cursor      string
+		errorString string
+	}{
+		{
+			name:        "Not Base64",
+			cursor:      "not a cursor!",
+			errorString: "couldn't decode cursor",
+		},
+		{
+			name:        "Not JSON",
+			cursor:      "bm90IGpzb24",
+			errorString: "couldn't parse cursor",
+		},
+		{
+			name:        "Missing Timestamp",
+			cursor:      SyncCursor{Seq: 7}.Encode(),
+			errorString: "cursor is incomplete",
+		},
+		{
+			name:        "Negative Sequence",
+			cursor:      SyncCursor{Seq: -1, Since: time.Now()}.Encode(),
+			errorString: "cursor is incomplete",
+		},
+		{
+			name:        "Page Cursor",
+			cursor:      Cursor{CreatedAt: time.Now(), ID: uuid.New()}.Encode(),
+			errorString: "couldn't parse cursor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeSyncCursor(tt.cursor)
+			if err == nil {
+				t.Fatalf("DecodeSyncCursor() expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.errorString) {
+				t.Errorf("DecodeSyncCursor() error = %v, expected substring %v", err, tt.errorString)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:  "Empty Uses Default",
+			limit: "",
+			want:  20,
+		},
+		{
+			name:  "Within Range",
+			limit: "5",
+			want:  5,
+		},
+		{
+			name:  "Capped At Max",
+			limit: "1000",
+			want:  100,
+		},
+		{
+			name:    "Zero",
+			limit:   "0",
+			wantErr: true,
+		},
+		{
+			name:    "Not A Number",
+			limit:   "ten",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLimit(tt.limit, 20, 100)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package profanity
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Action says what happens to a chirp that contains a banned word.
+type Action string
+
+const (
+	// ActionMask replaces the word with "****".
+	ActionMask Action = "mask"
+	// ActionHold keeps the chirp out of sight until a moderator reviews it.
+	ActionHold Action = "hold"
+	// ActionReject refuses the chirp.
+	ActionReject Action = "reject"
+)
+
+const mask = "****"
+
+func ParseAction(s string) (Action, error) {
+	switch action := Action(s); action {
+	case ActionMask, ActionHold, ActionReject:
+		return action, nil
+	}
+	return "", fmt.Errorf("unknown action %q", s)
+}
+
+// severity orders actions so the strictest one matched wins.
+func (a Action) severity() int {
+	switch a {
+	case ActionMask:
+		return 1
+	case ActionHold:
+		return 2
+	case ActionReject:
+		return 3
+	}
+	return 0
+}
+
+type Rule struct {
+	Word   string
+	Action Action
+}
+
+// Filter finds banned words in chirp bodies. It is safe for concurrent use.
+type Filter struct {
+	words map[string]Action
+}
+
+func NewFilter(rules []Rule) *Filter {
+	f := &Filter{words: make(map[string]Action, len(rules))}
+	for _, rule := range rules {
+		if word := Normalize(rule.Word); word != "" {
+			f.words[word] = rule.Action
+		}
+	}
+	return f
+}
+
+type Result struct {
+	// Body is the checked body with every masked word replaced.
+	Body string
+	// Action is the strictest action of the banned words found, or "" if
+	// there were none.
+	Action Action
+	// Words are the banned words found, in normalized form.
+	Words []string
+}
+
+// Check looks for banned words in body. Words are compared after Unicode
+// normalization, case folding and undoing common leetspeak, so "KERFUFFLE",
+// "kérfuffle" and "k3rfuffl3!" all match a ban on "kerfuffle". Punctuation
+// around a word doesn't hide it, and is kept when the word is masked.
+// Invisible format characters, such as zero-width joiners and soft hyphens,
+// don't split a word either, whatever cleaned up body beforehand.
+func (f *Filter) Check(body string) Result {
+	result := Result{}
+	out := strings.Builder{}
+
+	last := 0
+	for _, token := range tokenize(body) {
+		word, action, start, end := f.match(body, token)
+		if action == "" {
+			continue
+		}
+
+		result.Words = append(result.Words, word)
+		if action.severity() > result.Action.severity() {
+			result.Action = action
+		}
+		if action == ActionMask {
+			out.WriteString(body[last:start])
+			out.WriteString(mask)
+			last = end
+		}
+	}
+	out.WriteString(body[last:])
+
+	result.Body = out.String()
+	return result
+}
+
+// match checks a token as a whole and then without the symbols around it,
+// returning the banned word found and the byte span to mask.
+func (f *Filter) match(body string, token span) (string, Action, int, int) {
+	spans := []span{token}
+	if core := trimSymbols(body, token); core != token && core.start < core.end {
+		spans = append(spans, core)
+	}
+
+	for _, s := range spans {
+		for _, candidate := range candidates(body[s.start:s.end]) {
+			if action, ok := f.words[candidate]; ok {
+				return candidate, action, s.start, s.end
+			}
+		}
+	}
+	return "", "", 0, 0
+}
+
+// Normalize folds a word to the form banned words are compared in:
+// compatibility characters are decomposed, accents and invisible format
+// characters dropped and case folded.
+func Normalize(word string) string {
+	decomposed := norm.NFKD.String(word)
+	stripped := strings.Map(func(r rune) rune {
+		if unicode.In(r, unicode.Mn, unicode.Cf) {
+			return -1
+		}
+		return r
+	}, decomposed)
+	return cases.Fold().String(strings.TrimSpace(stripped))
+}
+
+// leetspeak maps symbols commonly used in place of letters. "1" and "|"
+// can stand for either "i" or "l", so both readings are tried.
+var (
+	leetspeakI = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "@", "a", "$", "s", "!", "i", "|", "i")
+	leetspeakL = strings.NewReplacer("0", "o", "1", "l", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "@", "a", "$", "s", "!", "i", "|", "l")
+)
+
+func candidates(token string) []string {
+	normalized := Normalize(token)
+	return []string{
+		normalized,
+		leetspeakI.Replace(normalized),
+		leetspeakL.Replace(normalized),
+	}
+}
+
+type span struct {
+	start, end int
+}
+
+// tokenize splits body into runs of letters, digits, marks, format
+// characters and leetspeak symbols, so whitespace and other punctuation
+// separate words.
+func tokenize(body string) []span {
+	tokens := []span{}
+	start := -1
+	for i, r := range body {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, span{start, len(body)})
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || unicode.Is(unicode.Cf, r) || strings.ContainsRune("@$!|", r)
+}
+
+// trimSymbols drops the leading and trailing runes of a token that aren't
+// letters, digits or marks.
+func trimSymbols(body string, token span) span {
+	for token.start < token.end {
+		r, size := utf8.DecodeRuneInString(body[token.start:token.end])
+		if !strings.ContainsRune("@$!|", r) {
+			break
+		}
+		token.start += size
+	}
+	for token.start < token.end {
+		r, size := utf8.DecodeLastRuneInString(body[token.start:token.end])
+		if !strings.ContainsRune("@$!|", r) {
+			break
+		}
+		token.end -= size
+	}
+	return token
+}
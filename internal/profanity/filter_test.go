@@ -0,0 +1,160 @@
+package profanity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterCheck(t *testing.T) {
+	filter := NewFilter([]Rule{
+		{Word: "kerfuffle", Action: ActionMask},
+		{Word: "Sharbert", Action: ActionMask},
+		{Word: "fornax", Action: ActionHold},
+		{Word: "blorp", Action: ActionReject},
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantBody   string
+		wantAction Action
+		wantWords  []string
+	}{
+		{
+			name:       "Clean",
+			body:       "This is a clean chirp",
+			wantBody:   "This is a clean chirp",
+			wantAction: "",
+		},
+		{
+			name:       "Masked Word",
+			body:       "What a kerfuffle",
+			wantBody:   "What a ****",
+			wantAction: ActionMask,
+			wantWords:  []string{"kerfuffle"},
+		},
+		{
+			name:       "Punctuation And Newlines Kept",
+			body:       "Kerfuffle!\nsharbert, again",
+			wantBody:   "****!\n****, again",
+			wantAction: ActionMask,
+			wantWords:  []string{"kerfuffle", "sharbert"},
+		},
+		{
+			name:       "Leetspeak",
+			body:       "such a k3rfuffl3 and $h4rb3rt",
+			wantBody:   "such a **** and ****",
+			wantAction: ActionMask,
+			wantWords:  []string{"kerfuffle", "sharbert"},
+		},
+		{
+			name:       "Accents And Fullwidth Letters",
+			body:       "kérfüffle ｓｈａｒｂｅｒｔ",
+			wantBody:   "**** ****",
+			wantAction: ActionMask,
+			wantWords:  []string{"kerfuffle", "sharbert"},
+		},
+		{
+			name:       "Word Inside Another Word Is Not Matched",
+			body:       "kerfuffles",
+			wantBody:   "kerfuffles",
+			wantAction: "",
+		},
+		{
+			name:       "Strictest Action Wins",
+			body:       "kerfuffle fornax BLORP",
+			wantBody:   "**** fornax BLORP",
+			wantAction: ActionReject,
+			wantWords:  []string{"kerfuffle", "fornax", "blorp"},
+		},
+		{
+			name:       "Zero-Width Joiner Inside Word",
+			body:       "bl\u200dorp",
+			wantBody:   "bl\u200dorp",
+			wantAction: ActionReject,
+			wantWords:  []string{"blorp"},
+		},
+		{
+			name:       "Zero-Width Non-Joiner Inside Word",
+			body:       "bl\u200corp",
+			wantBody:   "bl\u200corp",
+			wantAction: ActionReject,
+			wantWords:  []string{"blorp"},
+		},
+		{
+			name:       "Soft Hyphen Inside Word",
+			body:       "bl\u00adorp",
+			wantBody:   "bl\u00adorp",
+			wantAction: ActionReject,
+			wantWords:  []string{"blorp"},
+		},
+		{
+			name:       "Invisible Times Inside Word",
+			body:       "bl\u2062orp",
+			wantBody:   "bl\u2062orp",
+			wantAction: ActionReject,
+			wantWords:  []string{"blorp"},
+		},
+		{
+			name:       "Masked Word With Format Characters",
+			body:       "what a ker\u00adfuf\u200dfle!",
+			wantBody:   "what a ****!",
+			wantAction: ActionMask,
+			wantWords:  []string{"kerfuffle"},
+		},
+		{
+			name:       "Ambiguous One",
+			body:       "b1orp",
+			wantBody:   "b1orp",
+			wantAction: ActionReject,
+			wantWords:  []string{"blorp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filter.Check(tt.body)
+			if got.Body != tt.wantBody {
+				t.Errorf("Check().Body = %q, want %q", got.Body, tt.wantBody)
+			}
+			if got.Action != tt.wantAction {
+				t.Errorf("Check().Action = %q, want %q", got.Action, tt.wantAction)
+			}
+			if !reflect.DeepEqual(got.Words, tt.wantWords) {
+				t.Errorf("Check().Words = %v, want %v", got.Words, tt.wantWords)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "Kerfuffle", want: "kerfuffle"},
+		{word: "  CAFÉ ", want: "cafe"},
+		{word: "Straße", want: "strasse"},
+		{word: "ｆｏｒｎａｘ", want: "fornax"},
+		{word: "for\u00adn\u200bax", want: "fornax"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := Normalize(tt.word); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	for _, s := range []string{"mask", "hold", "reject"} {
+		if _, err := ParseAction(s); err != nil {
+			t.Errorf("ParseAction(%q) unexpected error: %v", s, err)
+		}
+	}
+	if _, err := ParseAction("delete"); err == nil {
+		t.Errorf("ParseAction(%q) expected error but got none", "delete")
+	}
+}
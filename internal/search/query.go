@@ -0,0 +1,81 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ToTSQuery turns a user's search string into Postgres to_tsquery syntax.
+// Words are ANDed together, "quoted phrases" must appear in order,
+// a trailing * matches a prefix and a leading - excludes a word.
+// Anything that isn't a letter or a digit is dropped, so the result is
+// always safe to pass to to_tsquery.
+func ToTSQuery(q string) (string, error) {
+	var parts []string
+
+	for _, token := range tokenize(q) {
+		if token.phrase {
+			if phrase := joinLexemes(token.text, " <-> "); phrase != "" {
+				parts = append(parts, "("+phrase+")")
+			}
+			continue
+		}
+
+		negate := strings.HasPrefix(token.text, "-")
+		prefix := strings.HasSuffix(token.text, "*")
+		text := strings.TrimSuffix(strings.TrimPrefix(token.text, "-"), "*")
+
+		term := joinLexemes(text, " <-> ")
+		if term == "" {
+			continue
+		}
+		if prefix {
+			term += ":*"
+		}
+		if strings.Contains(term, "<->") {
+			term = "(" + term + ")"
+		}
+		if negate {
+			term = "!" + term
+		}
+		parts = append(parts, term)
+	}
+
+	if len(parts) == 0 {
+		return "", errors.New("search query is empty")
+	}
+	return strings.Join(parts, " & "), nil
+}
+
+type token struct {
+	text   string
+	phrase bool
+}
+
+func tokenize(q string) []token {
+	var tokens []token
+
+	for i, chunk := range strings.Split(q, `"`) {
+		// Odd chunks sit between a pair of quotes.
+		if i%2 == 1 {
+			tokens = append(tokens, token{text: chunk, phrase: true})
+			continue
+		}
+		for _, word := range strings.Fields(chunk) {
+			tokens = append(tokens, token{text: word})
+		}
+	}
+
+	return tokens
+}
+
+func joinLexemes(text, sep string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = "'" + strings.ToLower(word) + "'"
+	}
+	return strings.Join(words, sep)
+}
@@ -0,0 +1,80 @@
+package search
+
+import "testing"
+
+func TestToTSQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Single Word",
+			query: "gopher",
+			want:  "'gopher'",
+		},
+		{
+			name:  "Words Are ANDed And Lowercased",
+			query: "Hello  World",
+			want:  "'hello' & 'world'",
+		},
+		{
+			name:  "Quoted Phrase",
+			query: `"hello big world" again`,
+			want:  "('hello' <-> 'big' <-> 'world') & 'again'",
+		},
+		{
+			name:  "Prefix",
+			query: "gop*",
+			want:  "'gop':*",
+		},
+		{
+			name:  "Negated Word",
+			query: "go -rust",
+			want:  "'go' & !'rust'",
+		},
+		{
+			name:  "Punctuation Inside Word",
+			query: "don't*",
+			want:  "('don' <-> 't':*)",
+		},
+		{
+			name:  "Operators Are Stripped",
+			query: "a&b | !c:*",
+			want:  "('a' <-> 'b') & 'c':*",
+		},
+		{
+			name:  "Unicode Letters",
+			query: "日本語 Ünïcode",
+			want:  "'日本語' & 'ünïcode'",
+		},
+		{
+			name:  "Unterminated Quote",
+			query: `"open phrase`,
+			want:  "('open' <-> 'phrase')",
+		},
+		{
+			name:    "Empty",
+			query:   "   ",
+			wantErr: true,
+		},
+		{
+			name:    "Only Punctuation",
+			query:   `"" !!! *`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToTSQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToTSQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ToTSQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
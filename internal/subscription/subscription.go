@@ -0,0 +1,202 @@
+// Package subscription tracks the state of paid plans: trials, billing
+// periods, grace periods after a failed payment, and cancellation.
+package subscription
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	StatusTrialing = "trialing"
+	StatusActive   = "active"
+	// StatusPastDue means a renewal payment failed. The plan is kept until
+	// the grace period ends, in case the payment goes through after all.
+	StatusPastDue = "past_due"
+	// StatusCanceled means the subscription won't renew. The plan is kept
+	// until the end of the period that's been paid for.
+	StatusCanceled = "canceled"
+	// StatusExpired means the subscription no longer grants its plan. It's
+	// kept for the record; a new one is started to subscribe again.
+	StatusExpired = "expired"
+	// StatusLifetime means the plan was granted for good, outside of
+	// billing, and nothing that happens to payments changes it. Its period
+	// is meaningless.
+	StatusLifetime = "lifetime"
+)
+
+var (
+	ErrExpired   = errors.New("subscription has expired")
+	ErrNotActive = errors.New("subscription isn't active")
+	ErrLifetime  = errors.New("subscription is a lifetime grant")
+	// ErrNoChange means an event leaves the subscription as it was.
+	ErrNoChange = errors.New("event doesn't change the subscription")
+)
+
+// Event is something the payment provider reports about a subscription.
+type Event string
+
+const (
+	EventPaid          Event = "paid"
+	EventTrialStarted  Event = "trial_started"
+	EventPaymentFailed Event = "payment_failed"
+	EventCanceled      Event = "canceled"
+)
+
+// Terms are the lengths of a billing period, a free trial, and the grace
+// period after a period ends before a subscription that hasn't been paid
+// for lapses.
+type Terms struct {
+	Period time.Duration
+	Trial  time.Duration
+	Grace  time.Duration
+}
+
+// Subscription is the part of a subscription that decides whether, and
+// until when, it grants its plan.
+type Subscription struct {
+	Status             string
+	CurrentPeriodStart time.Time
+	CurrentPeriodEnd   time.Time
+	// TrialEndsAt is zero unless the subscription started as a trial.
+	TrialEndsAt time.Time
+	// GracePeriodEndsAt is when an active or past due subscription lapses
+	// if it isn't renewed.
+	GracePeriodEndsAt time.Time
+	// CanceledAt is zero unless the subscription was canceled.
+	CanceledAt time.Time
+}
+
+// Start begins a paid subscription at now.
+func (t Terms) Start(now time.Time) Subscription {
+	end := now.Add(t.Period)
+	return Subscription{
+		Status:             StatusActive,
+		CurrentPeriodStart: now,
+		CurrentPeriodEnd:   end,
+		GracePeriodEndsAt:  end.Add(t.Grace),
+	}
+}
+
+// StartTrial begins a free trial at now. A trial has no grace period.
+func (t Terms) StartTrial(now time.Time) Subscription {
+	end := now.Add(t.Trial)
+	return Subscription{
+		Status:             StatusTrialing,
+		CurrentPeriodStart: now,
+		CurrentPeriodEnd:   end,
+		TrialEndsAt:        end,
+		GracePeriodEndsAt:  end,
+	}
+}
+
+// Renew records a payment at now. It starts a new period, which takes over
+// from the current one if that hasn't ended yet, so paying early doesn't
+// lose any time. Renewing a canceled subscription takes the cancellation
+// back. A lifetime grant has nothing to renew.
+func (t Terms) Renew(s Subscription, now time.Time) (Subscription, error) {
+	switch s.Status {
+	case StatusExpired:
+		return s, ErrExpired
+	case StatusLifetime:
+		return s, ErrLifetime
+	}
+	start := now
+	if s.Status != StatusTrialing && s.CurrentPeriodEnd.After(now) {
+		start = s.CurrentPeriodEnd
+	}
+	renewed := t.Start(start)
+	renewed.TrialEndsAt = s.TrialEndsAt
+	return renewed, nil
+}
+
+// MarkPastDue records a failed renewal payment. Only active subscriptions
+// can fall past due; marking one that's already past due changes nothing.
+func MarkPastDue(s Subscription) (Subscription, error) {
+	switch s.Status {
+	case StatusActive:
+		s.Status = StatusPastDue
+		return s, nil
+	case StatusPastDue:
+		return s, nil
+	case StatusLifetime:
+		return s, ErrLifetime
+	default:
+		return s, ErrNotActive
+	}
+}
+
+// Cancel stops the subscription from renewing. Canceling one that's
+// already canceled changes nothing; a lifetime grant can't be canceled.
+func Cancel(s Subscription, now time.Time) (Subscription, error) {
+	switch s.Status {
+	case StatusExpired:
+		return s, ErrExpired
+	case StatusLifetime:
+		return s, ErrLifetime
+	case StatusCanceled:
+		return s, nil
+	}
+	s.Status = StatusCanceled
+	s.CanceledAt = now
+	return s, nil
+}
+
+// Apply works out the subscription after event at now. current is nil
+// when there's no subscription yet, in which case the result is a new one.
+// A provider that may report the same event twice without telling the two
+// apart isn't once-only: a payment for an active subscription is then
+// taken to be one that's already been recorded, rather than paying for
+// another period. Events that change nothing return ErrNoChange.
+func (t Terms) Apply(current *Subscription, event Event, now time.Time, onceOnly bool) (Subscription, error) {
+	if current == nil {
+		switch event {
+		case EventPaid:
+			return t.Start(now), nil
+		case EventTrialStarted:
+			return t.StartTrial(now), nil
+		default:
+			// Nothing to mark past due or cancel.
+			return Subscription{}, ErrNoChange
+		}
+	}
+
+	var (
+		s   Subscription
+		err error
+	)
+	switch event {
+	case EventPaid:
+		if !onceOnly && current.Status == StatusActive {
+			return *current, ErrNoChange
+		}
+		s, err = t.Renew(*current, now)
+	case EventPaymentFailed:
+		s, err = MarkPastDue(*current)
+	case EventCanceled:
+		s, err = Cancel(*current, now)
+	default:
+		// Trials are only for users without a subscription.
+		return *current, ErrNoChange
+	}
+	if errors.Is(err, ErrNotActive) || errors.Is(err, ErrLifetime) {
+		return *current, ErrNoChange
+	}
+	return s, err
+}
+
+// EntitledAt reports whether the subscription grants its plan at t. A
+// canceled subscription runs to the end of its period, and any other one
+// that's still billed to the end of its grace period.
+func (s Subscription) EntitledAt(t time.Time) bool {
+	switch s.Status {
+	case StatusLifetime:
+		return true
+	case StatusExpired:
+		return false
+	case StatusCanceled:
+		return t.Before(s.CurrentPeriodEnd)
+	default:
+		return t.Before(s.GracePeriodEndsAt)
+	}
+}
@@ -0,0 +1,329 @@
+package subscription
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	testTerms = Terms{
+		Period: 30 * 24 * time.Hour,
+		Trial:  14 * 24 * time.Hour,
+		Grace:  3 * 24 * time.Hour,
+	}
+	testNow = time.Date(2024, 12, 18, 10, 30, 0, 0, time.UTC)
+)
+
+func TestEntitledAt(t *testing.T) {
+	active := testTerms.Start(testNow)
+	pastDue, _ := MarkPastDue(active)
+	canceled, _ := Cancel(active, testNow)
+	trial := testTerms.StartTrial(testNow)
+	expired := active
+	expired.Status = StatusExpired
+	lifetime := active
+	lifetime.Status = StatusLifetime
+
+	tests := []struct {
+		name         string
+		subscription Subscription
+		at           time.Time
+		want         bool
+	}{
+		{
+			name:         "Active During Period",
+			subscription: active,
+			at:           testNow.Add(time.Hour),
+			want:         true,
+		},
+		{
+			name:         "Active In Grace Period",
+			subscription: active,
+			at:           active.CurrentPeriodEnd.Add(time.Hour),
+			want:         true,
+		},
+		{
+			name:         "Active After Grace Period",
+			subscription: active,
+			at:           active.GracePeriodEndsAt,
+			want:         false,
+		},
+		{
+			name:         "Past Due In Grace Period",
+			subscription: pastDue,
+			at:           pastDue.CurrentPeriodEnd.Add(time.Hour),
+			want:         true,
+		},
+		{
+			name:         "Canceled Before Period End",
+			subscription: canceled,
+			at:           canceled.CurrentPeriodEnd.Add(-time.Hour),
+			want:         true,
+		},
+		{
+			name:         "Canceled Gets No Grace Period",
+			subscription: canceled,
+			at:           canceled.CurrentPeriodEnd.Add(time.Hour),
+			want:         false,
+		},
+		{
+			name:         "Trial Before End",
+			subscription: trial,
+			at:           trial.TrialEndsAt.Add(-time.Hour),
+			want:         true,
+		},
+		{
+			name:         "Trial After End",
+			subscription: trial,
+			at:           trial.TrialEndsAt,
+			want:         false,
+		},
+		{
+			name:         "Expired",
+			subscription: expired,
+			at:           testNow,
+			want:         false,
+		},
+		{
+			name:         "Lifetime Long After Period",
+			subscription: lifetime,
+			at:           lifetime.GracePeriodEndsAt.AddDate(10, 0, 0),
+			want:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.subscription.EntitledAt(tt.at); got != tt.want {
+				t.Errorf("EntitledAt(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenew(t *testing.T) {
+	active := testTerms.Start(testNow)
+	pastDue, _ := MarkPastDue(active)
+	canceled, _ := Cancel(active, testNow)
+	trial := testTerms.StartTrial(testNow)
+	expired := active
+	expired.Status = StatusExpired
+	lifetime := active
+	lifetime.Status = StatusLifetime
+
+	tests := []struct {
+		name         string
+		subscription Subscription
+		at           time.Time
+		wantStart    time.Time
+		wantErr      error
+	}{
+		{
+			name:         "Early Renewal Keeps Remaining Time",
+			subscription: active,
+			at:           testNow.Add(24 * time.Hour),
+			wantStart:    active.CurrentPeriodEnd,
+		},
+		{
+			name:         "Past Due Starts From Payment",
+			subscription: pastDue,
+			at:           pastDue.CurrentPeriodEnd.Add(24 * time.Hour),
+			wantStart:    pastDue.CurrentPeriodEnd.Add(24 * time.Hour),
+		},
+		{
+			name:         "Canceled Is Taken Back",
+			subscription: canceled,
+			at:           testNow.Add(24 * time.Hour),
+			wantStart:    canceled.CurrentPeriodEnd,
+		},
+		{
+			name:         "Trial Converts From Payment",
+			subscription: trial,
+			at:           testNow.Add(24 * time.Hour),
+			wantStart:    testNow.Add(24 * time.Hour),
+		},
+		{
+			name:         "Expired",
+			subscription: expired,
+			at:           testNow,
+			wantErr:      ErrExpired,
+		},
+		{
+			name:         "Lifetime",
+			subscription: lifetime,
+			at:           testNow,
+			wantErr:      ErrLifetime,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testTerms.Renew(tt.subscription, tt.at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Renew() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Status != StatusActive {
+				t.Errorf("Renew() status = %q, want %q", got.Status, StatusActive)
+			}
+			if !got.CurrentPeriodStart.Equal(tt.wantStart) {
+				t.Errorf("Renew() period start = %v, want %v", got.CurrentPeriodStart, tt.wantStart)
+			}
+			if want := tt.wantStart.Add(testTerms.Period); !got.CurrentPeriodEnd.Equal(want) {
+				t.Errorf("Renew() period end = %v, want %v", got.CurrentPeriodEnd, want)
+			}
+			if !got.CanceledAt.IsZero() {
+				t.Errorf("Renew() canceled at = %v, want zero", got.CanceledAt)
+			}
+			if !got.TrialEndsAt.Equal(tt.subscription.TrialEndsAt) {
+				t.Errorf("Renew() trial ends at = %v, want %v", got.TrialEndsAt, tt.subscription.TrialEndsAt)
+			}
+		})
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	active := testTerms.Start(testNow)
+	trial := testTerms.StartTrial(testNow)
+	expired := active
+	expired.Status = StatusExpired
+	lifetime := active
+	lifetime.Status = StatusLifetime
+
+	tests := []struct {
+		name       string
+		transition func() (Subscription, error)
+		wantStatus string
+		wantErr    error
+	}{
+		{
+			name:       "Active Falls Past Due",
+			transition: func() (Subscription, error) { return MarkPastDue(active) },
+			wantStatus: StatusPastDue,
+		},
+		{
+			name:       "Trial Can't Fall Past Due",
+			transition: func() (Subscription, error) { return MarkPastDue(trial) },
+			wantErr:    ErrNotActive,
+		},
+		{
+			name:       "Cancel Trial",
+			transition: func() (Subscription, error) { return Cancel(trial, testNow) },
+			wantStatus: StatusCanceled,
+		},
+		{
+			name:       "Cancel Expired",
+			transition: func() (Subscription, error) { return Cancel(expired, testNow) },
+			wantErr:    ErrExpired,
+		},
+		{
+			name:       "Lifetime Can't Fall Past Due",
+			transition: func() (Subscription, error) { return MarkPastDue(lifetime) },
+			wantErr:    ErrLifetime,
+		},
+		{
+			name:       "Cancel Lifetime",
+			transition: func() (Subscription, error) { return Cancel(lifetime, testNow) },
+			wantErr:    ErrLifetime,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.transition()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	active := testTerms.Start(testNow)
+	trial := testTerms.StartTrial(testNow)
+	pastDue, _ := MarkPastDue(active)
+	lifetime := active
+	lifetime.Status = StatusLifetime
+	later := testNow.Add(24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		current   *Subscription
+		event     Event
+		onceOnly  bool
+		wantErr   error
+		wantState Subscription
+	}{
+		{
+			name:      "Paid Starts Subscription",
+			event:     EventPaid,
+			onceOnly:  true,
+			wantState: testTerms.Start(later),
+		},
+		{
+			name:      "Paid Without ID Starts Subscription",
+			event:     EventPaid,
+			wantState: testTerms.Start(later),
+		},
+		{
+			name:      "Paid Renews Active",
+			current:   &active,
+			event:     EventPaid,
+			onceOnly:  true,
+			wantState: testTerms.Start(active.CurrentPeriodEnd),
+		},
+		{
+			name:    "Paid Without ID Leaves Active",
+			current: &active,
+			event:   EventPaid,
+			wantErr: ErrNoChange,
+		},
+		{
+			name:      "Paid Without ID Renews Past Due",
+			current:   &pastDue,
+			event:     EventPaid,
+			wantState: testTerms.Start(pastDue.CurrentPeriodEnd),
+		},
+		{
+			name:    "Trial Started With Subscription",
+			current: &trial,
+			event:   EventTrialStarted,
+			wantErr: ErrNoChange,
+		},
+		{
+			name:    "Canceled Without Subscription",
+			event:   EventCanceled,
+			wantErr: ErrNoChange,
+		},
+		{
+			name:    "Payment Failed For Trial",
+			current: &trial,
+			event:   EventPaymentFailed,
+			wantErr: ErrNoChange,
+		},
+		{
+			name:    "Canceled Lifetime",
+			current: &lifetime,
+			event:   EventCanceled,
+			wantErr: ErrNoChange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testTerms.Apply(tt.current, tt.event, later, tt.onceOnly)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got != tt.wantState {
+				t.Errorf("state = %+v, want %+v", got, tt.wantState)
+			}
+		})
+	}
+}
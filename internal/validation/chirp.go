@@ -0,0 +1,146 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/katsuikeda/chirpy/internal/profanity"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ChirpDraft is a chirp on its way through a validation chain. Validators
+// may rewrite the body, so later ones see the cleaned-up text.
+type ChirpDraft struct {
+	Body       string
+	MediaCount int
+	// Hold is set when the chirp has to be reviewed before it's published.
+	Hold bool
+}
+
+// ChirpValidator checks, and may clean up, a draft. Problems with the
+// draft are reported as FieldErrors; any other error means the check
+// itself failed.
+type ChirpValidator interface {
+	Validate(draft *ChirpDraft) error
+}
+
+// ChirpValidatorFunc adapts a function to a ChirpValidator.
+type ChirpValidatorFunc func(draft *ChirpDraft) error
+
+func (f ChirpValidatorFunc) Validate(draft *ChirpDraft) error {
+	return f(draft)
+}
+
+// ChirpChain runs validators in order. It keeps going after a validator
+// reports FieldErrors so they can all be returned together, but stops at
+// the first other error.
+type ChirpChain []ChirpValidator
+
+func (c ChirpChain) Validate(draft *ChirpDraft) error {
+	fieldErrs := FieldErrors{}
+	for _, validator := range c {
+		err := validator.Validate(draft)
+		if err == nil {
+			continue
+		}
+		var errs FieldErrors
+		if !errors.As(err, &errs) {
+			return err
+		}
+		fieldErrs = append(fieldErrs, errs...)
+	}
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+	return nil
+}
+
+// NormalizeBody puts the body in Unicode NFC, so the same text always has
+// the same bytes however it was typed.
+var NormalizeBody = ChirpValidatorFunc(func(draft *ChirpDraft) error {
+	draft.Body = norm.NFC.String(draft.Body)
+	return nil
+})
+
+// StripInvisible removes control characters other than newlines and tabs,
+// and every invisible format character: byte order marks, zero-width
+// spaces, soft hyphens, bidi controls and the like. The one exception is a
+// zero-width joiner between two emoji, where it builds a sequence such as
+// a family or a profession.
+var StripInvisible = ChirpValidatorFunc(func(draft *ChirpDraft) error {
+	runes := []rune(draft.Body)
+	out := strings.Builder{}
+	for i, r := range runes {
+		switch {
+		case r == '\n' || r == '\t':
+		case unicode.IsControl(r):
+			continue
+		case r == '\u200d':
+			if i == 0 || i == len(runes)-1 || !isEmoji(runes[i-1]) || !isEmoji(runes[i+1]) {
+				continue
+			}
+		case unicode.Is(unicode.Cf, r):
+			continue
+		}
+		out.WriteRune(r)
+	}
+	draft.Body = out.String()
+	return nil
+})
+
+// TrimBody removes leading and trailing whitespace.
+var TrimBody = ChirpValidatorFunc(func(draft *ChirpDraft) error {
+	draft.Body = strings.TrimSpace(draft.Body)
+	return nil
+})
+
+// RequireContent rejects a chirp with neither text nor attachments.
+var RequireContent = ChirpValidatorFunc(func(draft *ChirpDraft) error {
+	if draft.Body == "" && draft.MediaCount == 0 {
+		return FieldErrors{{Field: "body", Code: "required", Message: "Chirp can't be empty"}}
+	}
+	return nil
+})
+
+// MaxLength limits the body to maxLength user-perceived characters. Each
+// grapheme cluster counts once, so an emoji, a flag or a letter with
+// combining accents is one character however many code points it takes.
+func MaxLength(maxLength int) ChirpValidator {
+	return ChirpValidatorFunc(func(draft *ChirpDraft) error {
+		if length := uniseg.GraphemeClusterCount(draft.Body); length > maxLength {
+			return FieldErrors{{
+				Field:   "body",
+				Code:    "too_long",
+				Message: fmt.Sprintf("Chirp is too long: %d characters, the limit is %d", length, maxLength),
+			}}
+		}
+		return nil
+	})
+}
+
+// FilterProfanity applies the banned word list: masked words are replaced,
+// a rejected word fails validation and a held word marks the draft for
+// review.
+func FilterProfanity(filter *profanity.Filter) ChirpValidator {
+	return ChirpValidatorFunc(func(draft *ChirpDraft) error {
+		result := filter.Check(draft.Body)
+		switch result.Action {
+		case profanity.ActionReject:
+			return FieldErrors{{Field: "body", Code: "banned_word", Message: "Chirp contains a banned word"}}
+		case profanity.ActionHold:
+			draft.Hold = true
+		}
+		draft.Body = result.Body
+		return nil
+	})
+}
+
+// isEmoji reports whether r can sit on either side of a zero-width joiner in
+// an emoji sequence: a pictograph, a skin tone modifier or the variation
+// selector that asks for emoji presentation.
+func isEmoji(r rune) bool {
+	return unicode.Is(unicode.So, r) || (r >= '\U0001f3fb' && r <= '\U0001f3ff') || r == '\ufe0f'
+}
@@ -0,0 +1,178 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katsuikeda/chirpy/internal/profanity"
+)
+
+func TestChirpChain(t *testing.T) {
+	chain := ChirpChain{
+		NormalizeBody,
+		StripInvisible,
+		TrimBody,
+		RequireContent,
+		MaxLength(10),
+		FilterProfanity(profanity.NewFilter([]profanity.Rule{
+			{Word: "kerfuffle", Action: profanity.ActionMask},
+			{Word: "fornax", Action: profanity.ActionHold},
+			{Word: "blorp", Action: profanity.ActionReject},
+		})),
+	}
+
+	tests := []struct {
+		name      string
+		draft     ChirpDraft
+		wantBody  string
+		wantHold  bool
+		wantCodes []string
+	}{
+		{
+			name:     "Plain",
+			draft:    ChirpDraft{Body: "hello"},
+			wantBody: "hello",
+		},
+		{
+			name:     "Composed To NFC",
+			draft:    ChirpDraft{Body: "cafe\u0301"},
+			wantBody: "caf\u00e9",
+		},
+		{
+			name:     "Trimmed And Invisible Characters Stripped",
+			draft:    ChirpDraft{Body: " \ufeffhi\u200b\x00 there\u200d\n"},
+			wantBody: "hi there",
+		},
+		{
+			name:     "Bidi Overrides Stripped",
+			draft:    ChirpDraft{Body: "abc\u202egnp.exe\u202c"},
+			wantBody: "abcgnp.exe",
+		},
+		{
+			name:     "Bidi Isolates Stripped",
+			draft:    ChirpDraft{Body: "\u2066hi\u2069 \u2067there\u2068\u202a"},
+			wantBody: "hi there",
+		},
+		{
+			name:     "Emoji Joiner Kept",
+			draft:    ChirpDraft{Body: "👩\u200d💻"},
+			wantBody: "👩\u200d💻",
+		},
+		{
+			name:     "Emoji Count As One Character Each",
+			draft:    ChirpDraft{Body: strings.Repeat("🇯🇵", 10)},
+			wantBody: strings.Repeat("🇯🇵", 10),
+		},
+		{
+			name:      "Too Long",
+			draft:     ChirpDraft{Body: strings.Repeat("日", 11)},
+			wantCodes: []string{"too_long"},
+		},
+		{
+			name:      "Empty After Cleanup",
+			draft:     ChirpDraft{Body: " \u200b "},
+			wantCodes: []string{"required"},
+		},
+		{
+			name:     "Empty With Media",
+			draft:    ChirpDraft{Body: "", MediaCount: 1},
+			wantBody: "",
+		},
+		{
+			name:     "Masked",
+			draft:    ChirpDraft{Body: "kerfuffle!"},
+			wantBody: "****!",
+		},
+		{
+			name:     "Held",
+			draft:    ChirpDraft{Body: "fornax"},
+			wantBody: "fornax",
+			wantHold: true,
+		},
+		{
+			name:      "Banned Word Split By Zero-Width Joiner",
+			draft:     ChirpDraft{Body: "bl\u200dorp"},
+			wantCodes: []string{"banned_word"},
+		},
+		{
+			name:      "Banned Word Split By Zero-Width Non-Joiner",
+			draft:     ChirpDraft{Body: "bl\u200corp"},
+			wantCodes: []string{"banned_word"},
+		},
+		{
+			name:      "Banned Word Split By Soft Hyphen",
+			draft:     ChirpDraft{Body: "bl\u00adorp"},
+			wantCodes: []string{"banned_word"},
+		},
+		{
+			name:      "Banned Word Split By Invisible Times",
+			draft:     ChirpDraft{Body: "bl\u2062orp"},
+			wantCodes: []string{"banned_word"},
+		},
+		{
+			name:     "Masked Word Split By Format Characters",
+			draft:    ChirpDraft{Body: "ker\u00adfuf\u200cfle"},
+			wantBody: "****",
+		},
+		{
+			name:     "Skin Tone Emoji Joiner Kept",
+			draft:    ChirpDraft{Body: "👩🏽\u200d🚀"},
+			wantBody: "👩🏽\u200d🚀",
+		},
+		{
+			name:      "All Errors Reported",
+			draft:     ChirpDraft{Body: "blorp blorp"},
+			wantCodes: []string{"too_long", "banned_word"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			draft := tt.draft
+			err := chain.Validate(&draft)
+
+			if len(tt.wantCodes) > 0 {
+				var fieldErrs FieldErrors
+				if !errors.As(err, &fieldErrs) {
+					t.Fatalf("Validate() error = %v, want FieldErrors", err)
+				}
+				codes := []string{}
+				for _, fieldErr := range fieldErrs {
+					codes = append(codes, fieldErr.Code)
+				}
+				if !reflect.DeepEqual(codes, tt.wantCodes) {
+					t.Errorf("Validate() codes = %v, want %v", codes, tt.wantCodes)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Validate() unexpected error: %v", err)
+			}
+			if draft.Body != tt.wantBody {
+				t.Errorf("Validate() body = %q, want %q", draft.Body, tt.wantBody)
+			}
+			if draft.Hold != tt.wantHold {
+				t.Errorf("Validate() hold = %v, want %v", draft.Hold, tt.wantHold)
+			}
+		})
+	}
+}
+
+func TestChirpChainStopsOnInternalError(t *testing.T) {
+	internalErr := errors.New("boom")
+	ran := false
+	chain := ChirpChain{
+		ChirpValidatorFunc(func(*ChirpDraft) error { return internalErr }),
+		ChirpValidatorFunc(func(*ChirpDraft) error { ran = true; return nil }),
+	}
+
+	if err := chain.Validate(&ChirpDraft{Body: "hi"}); !errors.Is(err, internalErr) {
+		t.Errorf("Validate() error = %v, want %v", err, internalErr)
+	}
+	if ran {
+		t.Errorf("Validate() kept running after an internal error")
+	}
+}
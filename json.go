@@ -2,8 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+
+	"github.com/katsuikeda/chirpy/internal/validation"
 )
 
 func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
@@ -22,6 +25,26 @@ func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
 	})
 }
 
+// respondWithValidationError reports validation.FieldErrors as a 400 with
+// one entry per problem. The first problem is also given as the error
+// message, for clients that only show one. Any other error is a 500.
+func respondWithValidationError(w http.ResponseWriter, err error) {
+	var fieldErrs validation.FieldErrors
+	if !errors.As(err, &fieldErrs) || len(fieldErrs) == 0 {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't validate request", err)
+		return
+	}
+
+	type errorResponse struct {
+		Error  string                 `json:"error"`
+		Fields validation.FieldErrors `json:"fields"`
+	}
+	respondWithJSON(w, http.StatusBadRequest, errorResponse{
+		Error:  fieldErrs[0].Message,
+		Fields: fieldErrs,
+	})
+}
+
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	data, err := json.Marshal(payload)
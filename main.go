@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/katsuikeda/chirpy/internal/blobstore"
 	"github.com/katsuikeda/chirpy/internal/database"
+	"github.com/katsuikeda/chirpy/internal/profanity"
+	"github.com/katsuikeda/chirpy/internal/subscription"
 	_ "github.com/lib/pq"
 )
 
@@ -18,11 +26,19 @@ const (
 )
 
 type apiConfig struct {
-	fileserverHits atomic.Int32
-	db             *database.Queries
-	platform       string
-	jwtSecret      string
-	polkaKey       string
+	fileserverHits          atomic.Int32
+	db                      *database.Queries
+	dbConn                  *sql.DB
+	platform                string
+	jwtSecret               string
+	polkaKey                string
+	subscriptionTerms       subscription.Terms
+	entitlements            *Entitlements
+	chirpTrashRetention     time.Duration
+	draftTombstoneRetention time.Duration
+	reactions               map[string]struct{}
+	blobStore               blobstore.BlobStore
+	profanityFilter         atomic.Pointer[profanity.Filter]
 }
 
 func main() {
@@ -45,21 +61,84 @@ func main() {
 	if polkaKey == "" {
 		log.Fatal("POLKA_KEY environment variable is not set")
 	}
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		log.Fatal("MEDIA_DIR must be set")
+	}
+	reactionEmoji := os.Getenv("CHIRP_REACTIONS")
+	if reactionEmoji == "" {
+		reactionEmoji = defaultReactionEmoji
+	}
+	trendingTagsInterval := getEnvDuration("TRENDING_TAGS_INTERVAL", 5*time.Minute)
+	scheduledChirpsInterval := getEnvDuration("SCHEDULED_CHIRPS_INTERVAL", 30*time.Second)
+	chirpTrashRetention := getEnvDuration("CHIRP_TRASH_RETENTION", 30*24*time.Hour)
+	trashPurgeInterval := getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour)
+	draftTombstoneRetention := getEnvDuration("DRAFT_TOMBSTONE_RETENTION", 30*24*time.Hour)
+	draftPurgeInterval := getEnvDuration("DRAFT_PURGE_INTERVAL", time.Hour)
+	mutedWordsPurgeInterval := getEnvDuration("MUTED_WORDS_PURGE_INTERVAL", time.Hour)
+	pollFinalizeInterval := getEnvDuration("POLL_FINALIZE_INTERVAL", time.Minute)
+	chirpReapInterval := getEnvDuration("CHIRP_REAP_INTERVAL", time.Minute)
+	subscriptionExpiryInterval := getEnvDuration("SUBSCRIPTION_EXPIRY_INTERVAL", 5*time.Minute)
+	subscriptionTerms := subscription.Terms{
+		Period: getEnvDuration("SUBSCRIPTION_PERIOD", 30*24*time.Hour),
+		Trial:  getEnvDuration("SUBSCRIPTION_TRIAL", 14*24*time.Hour),
+		Grace:  getEnvDuration("SUBSCRIPTION_GRACE_PERIOD", 3*24*time.Hour),
+	}
+	freePlanLimits.chirpEditWindow = getEnvDuration("CHIRP_EDIT_WINDOW", freePlanLimits.chirpEditWindow)
+	chirpyRedPlanLimits.chirpEditWindow = getEnvDuration("CHIRP_EDIT_WINDOW_RED", chirpyRedPlanLimits.chirpEditWindow)
+	freePlanLimits.maxPinnedChirps = getEnvInt("MAX_PINNED_CHIRPS", freePlanLimits.maxPinnedChirps)
+	chirpyRedPlanLimits.maxPinnedChirps = getEnvInt("MAX_PINNED_CHIRPS_RED", chirpyRedPlanLimits.maxPinnedChirps)
 
+	dbURL, err := withUTCTimeZone(dbURL)
+	if err != nil {
+		log.Fatalf("Error parsing DB_URL: %v", err)
+	}
 	dbConn, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Fatalf("Error opening database %v", err)
 	}
 	dbQueries := database.New(dbConn)
 
+	blobStore, err := blobstore.NewLocalStore(mediaDir)
+	if err != nil {
+		log.Fatalf("Error opening media store: %v", err)
+	}
+
 	apiCfg := &apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             dbQueries,
-		platform:       platform,
-		jwtSecret:      jwtSecret,
-		polkaKey:       polkaKey,
+		fileserverHits:    atomic.Int32{},
+		db:                dbQueries,
+		dbConn:            dbConn,
+		platform:          platform,
+		jwtSecret:         jwtSecret,
+		polkaKey:          polkaKey,
+		subscriptionTerms: subscriptionTerms,
+		entitlements: &Entitlements{
+			db: dbQueries,
+			plans: map[string]planLimits{
+				planFree:      freePlanLimits,
+				planChirpyRed: chirpyRedPlanLimits,
+			},
+		},
+		chirpTrashRetention:     chirpTrashRetention,
+		draftTombstoneRetention: draftTombstoneRetention,
+		reactions:               parseReactions(reactionEmoji),
+		blobStore:               blobStore,
+	}
+
+	if err := apiCfg.reloadBannedWords(context.Background()); err != nil {
+		log.Fatalf("Error loading banned words: %v", err)
 	}
 
+	go runPeriodically(context.Background(), "banned words reload", time.Minute, apiCfg.reloadBannedWords)
+	go runPeriodically(context.Background(), "trending tags refresh", trendingTagsInterval, apiCfg.refreshTrendingTags)
+	go runPeriodically(context.Background(), "scheduled chirp publisher", scheduledChirpsInterval, apiCfg.publishDueChirps)
+	go runPeriodically(context.Background(), "trash purge", trashPurgeInterval, apiCfg.purgeTrashedChirps)
+	go runPeriodically(context.Background(), "draft purge", draftPurgeInterval, apiCfg.purgeDeletedDrafts)
+	go runPeriodically(context.Background(), "muted word expiry", mutedWordsPurgeInterval, apiCfg.deleteExpiredMutedWords)
+	go runPeriodically(context.Background(), "poll finalizer", pollFinalizeInterval, apiCfg.finalizeClosedPolls)
+	go runPeriodically(context.Background(), "chirp reaper", chirpReapInterval, apiCfg.reapChirps)
+	go runPeriodically(context.Background(), "subscription expiry", subscriptionExpiryInterval, apiCfg.expireLapsedSubscriptions)
+
 	mux := http.NewServeMux()
 
 	fsHandler := apiCfg.middlewareMetricsInc(http.StripPrefix("/app/", http.FileServer(http.Dir("."))))
@@ -67,15 +146,77 @@ func main() {
 
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
 
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.HandlerUpgradeUserToChirpyRed)
+	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerPolkaWebhooks)
 
 	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
 	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
+	mux.HandleFunc("GET /api/users/me/mentions", apiCfg.handlerGetMentions)
+	mux.HandleFunc("GET /api/users/me/trash", apiCfg.handlerGetTrash)
+	mux.HandleFunc("GET /api/users/me/blocks", apiCfg.handlerGetBlockedUsers)
+	mux.HandleFunc("GET /api/users/me/mutes", apiCfg.handlerGetMutedUsers)
+	mux.HandleFunc("GET /api/users/me/warnings", apiCfg.handlerGetWarnings)
+	mux.HandleFunc("PUT /api/users/me/pinned-chirps", apiCfg.handlerReorderPinnedChirps)
+	mux.HandleFunc("GET /api/users/me/preferences", apiCfg.handlerGetPreferences)
+	mux.HandleFunc("PUT /api/users/me/preferences", apiCfg.handlerUpdatePreferences)
+	mux.HandleFunc("GET /api/users/me/bookmarks", apiCfg.handlerGetBookmarks)
+	mux.HandleFunc("GET /api/users/me/bookmark-folders", apiCfg.handlerGetBookmarkFolders)
+	mux.HandleFunc("POST /api/users/me/bookmark-folders", apiCfg.handlerCreateBookmarkFolder)
+	mux.HandleFunc("PUT /api/users/me/bookmark-folders/{folderID}", apiCfg.handlerRenameBookmarkFolder)
+	mux.HandleFunc("DELETE /api/users/me/bookmark-folders/{folderID}", apiCfg.handlerDeleteBookmarkFolder)
+	mux.HandleFunc("GET /api/users/me/muted-words", apiCfg.handlerGetMutedWords)
+	mux.HandleFunc("POST /api/users/me/muted-words", apiCfg.handlerCreateMutedWord)
+	mux.HandleFunc("DELETE /api/users/me/muted-words/{mutedWordID}", apiCfg.handlerDeleteMutedWord)
+	mux.HandleFunc("GET /api/users/me/scheduled-chirps", apiCfg.handlerGetScheduledChirps)
+	mux.HandleFunc("PUT /api/users/me/scheduled-chirps/{chirpID}", apiCfg.handlerRescheduleChirp)
+	mux.HandleFunc("DELETE /api/users/me/scheduled-chirps/{chirpID}", apiCfg.handlerCancelScheduledChirp)
+
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.handlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.handlerUnfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.handlerGetFollowing)
+	mux.HandleFunc("POST /api/users/{userID}/block", apiCfg.handlerBlockUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/block", apiCfg.handlerUnblockUser)
+	mux.HandleFunc("POST /api/users/{userID}/mute", apiCfg.handlerMuteUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", apiCfg.handlerUnmuteUser)
+
+	mux.HandleFunc("GET /api/timeline/home", apiCfg.handlerGetHomeTimeline)
 
 	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
+	mux.HandleFunc("GET /api/chirps/search", apiCfg.handlerSearchChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerGetChirpByID)
 	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.handlerUpdateChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiCfg.handlerGetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.handlerGetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.handlerRechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.handlerUndoRechirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/reactions/{reaction}", apiCfg.handlerAddReaction)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/reactions/{reaction}", apiCfg.handlerRemoveReaction)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirpByID)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", apiCfg.handlerRestoreChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", apiCfg.handlerPinChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", apiCfg.handlerUnpinChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiCfg.handlerBookmarkChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.handlerUnbookmarkChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", apiCfg.handlerVoteInPoll)
+
+	mux.HandleFunc("GET /api/drafts", apiCfg.handlerGetDrafts)
+	mux.HandleFunc("POST /api/drafts", apiCfg.handlerCreateDraft)
+	mux.HandleFunc("GET /api/drafts/{draftID}", apiCfg.handlerGetDraft)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.handlerUpdateDraft)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.handlerDeleteDraft)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.handlerPublishDraft)
+
+	mux.HandleFunc("GET /api/reports", apiCfg.handlerGetReports)
+	mux.HandleFunc("POST /api/reports", apiCfg.handlerCreateReport)
+	mux.HandleFunc("GET /api/reports/{reportID}", apiCfg.handlerGetReport)
+
+	mux.HandleFunc("POST /api/media", apiCfg.handlerUploadMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}", apiCfg.handlerGetMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}/thumbnail", apiCfg.handlerGetMediaThumbnail)
+
+	mux.HandleFunc("GET /api/tags/trending", apiCfg.handlerGetTrendingTags)
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", apiCfg.handlerGetChirpsByHashtag)
 
 	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
@@ -83,12 +224,74 @@ func main() {
 
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
 	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.HandleFunc("GET /admin/banned-words", apiCfg.handlerGetBannedWords)
+	mux.HandleFunc("PUT /admin/banned-words/{word}", apiCfg.handlerPutBannedWord)
+	mux.HandleFunc("DELETE /admin/banned-words/{word}", apiCfg.handlerDeleteBannedWord)
+	mux.HandleFunc("GET /admin/held-chirps", apiCfg.handlerGetHeldChirps)
+	mux.HandleFunc("POST /admin/held-chirps/{chirpID}/approve", apiCfg.handlerApproveHeldChirp)
+	mux.HandleFunc("POST /admin/held-chirps/{chirpID}/reject", apiCfg.handlerRejectHeldChirp)
+	mux.HandleFunc("GET /admin/reports", apiCfg.handlerGetModerationQueue)
+	mux.HandleFunc("GET /admin/reports/{reportID}", apiCfg.handlerGetAdminReport)
+	mux.HandleFunc("POST /admin/reports/{reportID}/claim", apiCfg.handlerClaimReport)
+	mux.HandleFunc("POST /admin/reports/{reportID}/resolve", apiCfg.handlerResolveReport)
+	mux.HandleFunc("GET /admin/moderation-actions", apiCfg.handlerGetModerationActions)
+	mux.HandleFunc("PUT /admin/chirps/{chirpID}/content-flags", apiCfg.handlerFlagChirp)
 
 	srv := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: apiCfg.middlewareRejectSuspended(mux),
 	}
 
 	log.Printf("Serving on port: %s\n", port)
 	log.Fatal(srv.ListenAndServe())
 }
+
+// getEnvInt reads an optional setting that must be a whole number, zero or
+// more, falling back to the given default when it isn't set.
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("%s must be a whole number: %v", key, err)
+	}
+	if n < 0 {
+		log.Fatalf("%s can't be negative: %d", key, n)
+	}
+	return n
+}
+
+// getEnvDuration reads an optional duration setting such as "15m",
+// falling back to the given default when it isn't set.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("%s must be a duration like 15m: %v", key, err)
+	}
+	return duration
+}
+
+// withUTCTimeZone pins every database session to UTC. Timestamps are stored
+// without a time zone, mostly filled in by NOW(), and read back as UTC; that
+// only holds if NOW() is in UTC too.
+func withUTCTimeZone(dbURL string) (string, error) {
+	if !strings.Contains(dbURL, "://") {
+		return dbURL + " timezone=UTC", nil
+	}
+	u, err := url.Parse(dbURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("timezone", "UTC")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
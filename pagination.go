@@ -0,0 +1,92 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/pagination"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+type pageParams struct {
+	limit      int32
+	cursor     *pagination.Cursor
+	descending bool
+}
+
+func parsePageParams(query url.Values) (pageParams, error) {
+	limit, err := pagination.ParseLimit(query.Get("limit"), defaultPageLimit, maxPageLimit)
+	if err != nil {
+		return pageParams{}, err
+	}
+
+	page := pageParams{
+		limit:      int32(limit),
+		descending: query.Get("sort") == "desc",
+	}
+
+	if cursorString := query.Get("cursor"); cursorString != "" {
+		cursor, err := pagination.DecodeCursor(cursorString)
+		if err != nil {
+			return pageParams{}, err
+		}
+		page.cursor = &cursor
+	}
+
+	return page, nil
+}
+
+// fetchLimit asks for one extra row so we can tell whether another page exists.
+func (p pageParams) fetchLimit() int32 {
+	return p.limit + 1
+}
+
+func (p pageParams) cursorCreatedAt() sql.NullTime {
+	if p.cursor == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.cursor.CreatedAt, Valid: true}
+}
+
+func (p pageParams) cursorID() uuid.NullUUID {
+	if p.cursor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: p.cursor.ID, Valid: true}
+}
+
+func (p pageParams) cursorRank() sql.NullFloat64 {
+	if p.cursor == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: float64(p.cursor.Rank), Valid: true}
+}
+
+// nextPage trims a result fetched with fetchLimit down to the page size and
+// returns the cursor for the following page, or "" when this is the last one.
+func nextPage[T any](items []T, limit int32, cursorFor func(T) pagination.Cursor) ([]T, string) {
+	if len(items) <= int(limit) {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, cursorFor(items[len(items)-1]).Encode()
+}
+
+// setNextLink points a Link header at the page after this one, for lists
+// that are returned as a bare array and so have nowhere to put the cursor.
+// It does nothing on the last page.
+func setNextLink(w http.ResponseWriter, r *http.Request, nextCursor string) {
+	if nextCursor == "" {
+		return
+	}
+	query := r.URL.Query()
+	query.Set("cursor", nextCursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", "<"+next.String()+`>; rel="next"`)
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/database"
+)
+
+// populateChirps turns a list of database rows into API chirps as seen by
+// viewerID. Chirps with a content warning or marked sensitive come out
+// collapsed unless the viewer has chosen to expand them.
+func (cfg *apiConfig) populateChirps(ctx context.Context, viewerID uuid.NullUUID, dbChirps []database.Chirp) ([]Chirp, error) {
+	collapse, err := cfg.collapsesSensitive(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.loadChirps(ctx, viewerID, dbChirps, collapse)
+}
+
+// populateChirp turns a single database row into an API chirp. A chirp asked
+// for on its own is never collapsed.
+func (cfg *apiConfig) populateChirp(ctx context.Context, viewerID uuid.NullUUID, dbChirp database.Chirp) (Chirp, error) {
+	chirps, err := cfg.loadChirps(ctx, viewerID, []database.Chirp{dbChirp}, false)
+	if err != nil {
+		return Chirp{}, err
+	}
+	return chirps[0], nil
+}
+
+// collapsesSensitive reports whether lists should collapse sensitive chirps
+// for viewerID. Anonymous viewers get the default.
+func (cfg *apiConfig) collapsesSensitive(ctx context.Context, viewerID uuid.NullUUID) (bool, error) {
+	if !viewerID.Valid {
+		return true, nil
+	}
+	user, err := cfg.db.GetUserByID(ctx, viewerID.UUID)
+	if err != nil {
+		return false, fmt.Errorf("couldn't get viewer: %w", err)
+	}
+	return !user.ExpandSensitive, nil
+}
+
+// loadChirps does the work for populateChirps and populateChirp. Everything
+// the rows only reference, such as rechirped and quoted chirps, mentions,
+// attachments, polls and the viewer's own reactions, bookmarks and votes, is
+// loaded in batches for the whole page rather than once per chirp.
+func (cfg *apiConfig) loadChirps(ctx context.Context, viewerID uuid.NullUUID, dbChirps []database.Chirp, collapseSensitive bool) ([]Chirp, error) {
+	p := chirpPopulator{viewerID: viewerID, collapseSensitive: collapseSensitive}
+	dbChirps = hideTrashedChirps(dbChirps, viewerID)
+
+	var err error
+	p.rechirped, err = cfg.getChirpsByIDs(ctx, viewerID, referencedIDs(dbChirps, func(c database.Chirp) uuid.NullUUID {
+		return c.RechirpOfID
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	// A rechirp shows the quote inside the chirp it reshares, so look for
+	// quoted chirps in both the page and the rechirped originals.
+	withOriginals := append([]database.Chirp{}, dbChirps...)
+	for _, original := range p.rechirped {
+		withOriginals = append(withOriginals, original)
+	}
+	p.quoted, err = cfg.getChirpsByIDs(ctx, viewerID, referencedIDs(withOriginals, func(c database.Chirp) uuid.NullUUID {
+		return c.QuotedChirpID
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	all := withOriginals
+	for _, quoted := range p.quoted {
+		all = append(all, quoted)
+	}
+
+	p.reactionCounts = make(map[uuid.UUID]map[string]int32, len(all))
+	for _, dbChirp := range all {
+		counts := map[string]int32{}
+		if err := json.Unmarshal(dbChirp.ReactionCounts, &counts); err != nil {
+			return nil, fmt.Errorf("couldn't decode reaction counts of chirp %s: %w", dbChirp.ID, err)
+		}
+		p.reactionCounts[dbChirp.ID] = counts
+	}
+
+	chirpIDs := make([]uuid.UUID, len(all))
+	for i, dbChirp := range all {
+		chirpIDs[i] = dbChirp.ID
+	}
+
+	mentions, err := cfg.db.GetChirpMentions(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	p.mentions = map[uuid.UUID][]Mention{}
+	for _, mention := range mentions {
+		p.mentions[mention.ChirpID] = append(p.mentions[mention.ChirpID], Mention{
+			UserID: mention.UserID,
+			Start:  mention.StartOffset,
+			End:    mention.EndOffset,
+		})
+	}
+
+	attachments, err := cfg.db.GetChirpMedia(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	p.media = map[uuid.UUID][]MediaAttachment{}
+	for _, attachment := range attachments {
+		p.media[attachment.ChirpID] = append(p.media[attachment.ChirpID], MediaAttachment{
+			Media:   newMedia(attachment.Medium),
+			AltText: attachment.AltText,
+		})
+	}
+
+	p.viewerReactions = map[uuid.UUID]map[string]bool{}
+	if viewerID.Valid {
+		rows, err := cfg.db.GetUserReactionsForChirps(ctx, database.GetUserReactionsForChirpsParams{
+			UserID:   viewerID.UUID,
+			ChirpIDs: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if p.viewerReactions[row.ChirpID] == nil {
+				p.viewerReactions[row.ChirpID] = map[string]bool{}
+			}
+			p.viewerReactions[row.ChirpID][row.Reaction] = true
+		}
+	}
+
+	polls, err := cfg.db.GetPollsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	p.polls = map[uuid.UUID]database.Poll{}
+	for _, poll := range polls {
+		p.polls[poll.ChirpID] = poll
+	}
+	pollOptions, err := cfg.db.GetPollOptionsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	p.pollOptions = map[uuid.UUID][]database.PollOption{}
+	for _, option := range pollOptions {
+		p.pollOptions[option.ChirpID] = append(p.pollOptions[option.ChirpID], option)
+	}
+
+	p.viewerBookmarks = map[uuid.UUID]bool{}
+	if viewerID.Valid {
+		bookmarked, err := cfg.db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+			UserID:   viewerID.UUID,
+			ChirpIDs: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range bookmarked {
+			p.viewerBookmarks[id] = true
+		}
+	}
+
+	p.viewerVotes = map[uuid.UUID]int32{}
+	if viewerID.Valid && len(polls) > 0 {
+		votes, err := cfg.db.GetUserPollVotesForChirps(ctx, database.GetUserPollVotesForChirpsParams{
+			UserID:   viewerID.UUID,
+			ChirpIDs: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, vote := range votes {
+			p.viewerVotes[vote.ChirpID] = vote.Position
+		}
+	}
+
+	chirps := make([]Chirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		chirps[i] = p.chirp(dbChirp)
+		if dbChirp.RechirpOfID.Valid {
+			original := p.embedded(p.rechirped, dbChirp.RechirpOfID.UUID)
+			if row, ok := p.rechirped[dbChirp.RechirpOfID.UUID]; ok && row.QuotedChirpID.Valid {
+				original.QuotedChirp = p.embedded(p.quoted, row.QuotedChirpID.UUID)
+			}
+			chirps[i].RechirpOf = original
+		}
+		if dbChirp.QuotedChirpID.Valid {
+			chirps[i].QuotedChirp = p.embedded(p.quoted, dbChirp.QuotedChirpID.UUID)
+		}
+	}
+
+	return chirps, nil
+}
+
+// chirpPopulator holds what loadChirps loaded for a page of chirps.
+type chirpPopulator struct {
+	viewerID          uuid.NullUUID
+	collapseSensitive bool
+	rechirped         map[uuid.UUID]database.Chirp
+	quoted            map[uuid.UUID]database.Chirp
+	reactionCounts    map[uuid.UUID]map[string]int32
+	viewerReactions   map[uuid.UUID]map[string]bool
+	viewerBookmarks   map[uuid.UUID]bool
+	polls             map[uuid.UUID]database.Poll
+	pollOptions       map[uuid.UUID][]database.PollOption
+	viewerVotes       map[uuid.UUID]int32
+	mentions          map[uuid.UUID][]Mention
+	media             map[uuid.UUID][]MediaAttachment
+}
+
+func (p chirpPopulator) chirp(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:           dbChirp.ID,
+		CreatedAt:    dbChirp.CreatedAt,
+		UpdatedAt:    dbChirp.UpdatedAt,
+		PublishedAt:  dbChirp.PublishedAt,
+		Body:         dbChirp.Body,
+		UserID:       dbChirp.UserID,
+		ReplyCount:   dbChirp.ReplyCount,
+		RechirpCount: dbChirp.RechirpCount,
+		QuoteCount:   dbChirp.QuoteCount,
+		Reactions:    map[string]ReactionSummary{},
+		Status:       dbChirp.Status,
+		Visibility:   dbChirp.Visibility,
+		Deleted:      dbChirp.TombstonedAt.Valid,
+		Bookmarked:   p.viewerBookmarks[dbChirp.ID],
+	}
+	if !chirp.Deleted {
+		chirp.ContentWarning = dbChirp.ContentWarning
+		chirp.Sensitive = dbChirp.Sensitive
+		chirp.Mentions = p.mentions[dbChirp.ID]
+		chirp.Media = p.media[dbChirp.ID]
+		if poll, ok := p.polls[dbChirp.ID]; ok {
+			var votedFor *int32
+			if vote, ok := p.viewerVotes[dbChirp.ID]; ok {
+				votedFor = &vote
+			}
+			chirp.Poll = newPoll(poll, p.pollOptions[dbChirp.ID], votedFor)
+		}
+	}
+	if chirp.Mentions == nil {
+		chirp.Mentions = []Mention{}
+	}
+	if chirp.Media == nil {
+		chirp.Media = []MediaAttachment{}
+	}
+	if dbChirp.ParentChirpID.Valid {
+		chirp.InReplyTo = &dbChirp.ParentChirpID.UUID
+	}
+	if dbChirp.PublishAt.Valid {
+		chirp.PublishAt = &dbChirp.PublishAt.Time
+	}
+	if dbChirp.ExpiresAt.Valid {
+		chirp.ExpiresAt = &dbChirp.ExpiresAt.Time
+	}
+	if dbChirp.DeletedAt.Valid && !chirp.Deleted {
+		chirp.DeletedAt = &dbChirp.DeletedAt.Time
+	}
+	if p.collapseSensitive && (chirp.Sensitive || chirp.ContentWarning != "") &&
+		!(p.viewerID.Valid && p.viewerID.UUID == dbChirp.UserID) {
+		chirp.collapse()
+	}
+	for reaction, count := range p.reactionCounts[dbChirp.ID] {
+		chirp.Reactions[reaction] = ReactionSummary{
+			Count:   count,
+			Reacted: p.viewerReactions[dbChirp.ID][reaction],
+		}
+	}
+	return chirp
+}
+
+// embedded looks up a referenced chirp. One that can no longer be loaded
+// is still returned, as a deleted placeholder, so clients can tell the
+// reference was there.
+func (p chirpPopulator) embedded(chirps map[uuid.UUID]database.Chirp, id uuid.UUID) *Chirp {
+	dbChirp, ok := chirps[id]
+	if !ok {
+		return &Chirp{ID: id, Reactions: map[string]ReactionSummary{}, Mentions: []Mention{}, Media: []MediaAttachment{}, Deleted: true}
+	}
+	chirp := p.chirp(dbChirp)
+	return &chirp
+}
+
+// unavailableChirp stands in for a chirp the viewer can't read, giving
+// away nothing but its ID.
+func unavailableChirp(id uuid.UUID) Chirp {
+	return Chirp{ID: id, Reactions: map[string]ReactionSummary{}, Mentions: []Mention{}, Media: []MediaAttachment{}, Unavailable: true}
+}
+
+// hideTrashedChirps blanks out chirps in the trash, which only make it onto
+// a page as ancestors in a thread, for everyone but their author. They come
+// out looking the same as a tombstone.
+func hideTrashedChirps(dbChirps []database.Chirp, viewerID uuid.NullUUID) []database.Chirp {
+	hidden := make([]database.Chirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		if dbChirp.DeletedAt.Valid && !dbChirp.TombstonedAt.Valid && !(viewerID.Valid && viewerID.UUID == dbChirp.UserID) {
+			dbChirp.Body = ""
+			dbChirp.QuotedChirpID = uuid.NullUUID{}
+			dbChirp.RechirpCount = 0
+			dbChirp.ReactionCounts = json.RawMessage("{}")
+			dbChirp.TombstonedAt = dbChirp.DeletedAt
+		}
+		hidden[i] = dbChirp
+	}
+	return hidden
+}
+
+func (cfg *apiConfig) getChirpsByIDs(ctx context.Context, viewerID uuid.NullUUID, ids []uuid.UUID) (map[uuid.UUID]database.Chirp, error) {
+	chirps := make(map[uuid.UUID]database.Chirp, len(ids))
+	if len(ids) == 0 {
+		return chirps, nil
+	}
+
+	dbChirps, err := cfg.db.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+		IDs:      ids,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, dbChirp := range dbChirps {
+		chirps[dbChirp.ID] = dbChirp
+	}
+	return chirps, nil
+}
+
+func referencedIDs(dbChirps []database.Chirp, reference func(database.Chirp) uuid.NullUUID) []uuid.UUID {
+	seen := map[uuid.UUID]struct{}{}
+	ids := []uuid.UUID{}
+	for _, dbChirp := range dbChirps {
+		id := reference(dbChirp)
+		if !id.Valid {
+			continue
+		}
+		if _, ok := seen[id.UUID]; ok {
+			continue
+		}
+		seen[id.UUID] = struct{}{}
+		ids = append(ids, id.UUID)
+	}
+	return ids
+}
+
+// collapse blanks a chirp's content, keeping the body aside for muted
+// words to match against.
+func (c *Chirp) collapse() {
+	if c.Collapsed {
+		return
+	}
+	c.Collapsed = true
+	c.collapsedBody = c.Body
+	c.Body = ""
+	c.Mentions = []Mention{}
+	c.Media = []MediaAttachment{}
+	c.Poll = nil
+}
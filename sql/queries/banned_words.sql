@@ -0,0 +1,16 @@
+-- name: GetBannedWords :many
+SELECT * FROM banned_words
+ORDER BY word;
+
+-- name: UpsertBannedWord :one
+INSERT INTO
+    banned_words (word, created_at, updated_at, action)
+VALUES
+    ($1, NOW (), NOW (), $2)
+ON CONFLICT (word) DO UPDATE
+SET updated_at = NOW (), action = EXCLUDED.action
+RETURNING *;
+
+-- name: DeleteBannedWord :execrows
+DELETE FROM banned_words
+WHERE word = $1;
\ No newline at end of file
@@ -0,0 +1,46 @@
+-- name: CreateBlock :execrows
+INSERT INTO
+    blocks (blocker_id, blocked_id, created_at)
+VALUES
+    ($1, $2, NOW ())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: GetBlockedUsers :many
+SELECT users.id, users.handle, blocks.created_at FROM blocks
+JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = sqlc.arg('user_id')
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (blocks.created_at, blocks.blocked_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY blocks.created_at DESC, blocks.blocked_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CreateMute :execrows
+INSERT INTO
+    mutes (muter_id, muted_id, created_at)
+VALUES
+    ($1, $2, NOW ())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMute :execrows
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: GetMutedUsers :many
+SELECT users.id, users.handle, mutes.created_at FROM mutes
+JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = sqlc.arg('user_id')
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (mutes.created_at, mutes.muted_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY mutes.created_at DESC, mutes.muted_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: BlockExistsBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = $1 AND blocked_id = $2)
+        OR (blocker_id = $2 AND blocked_id = $1)
+);
\ No newline at end of file
@@ -0,0 +1,60 @@
+-- name: CreateBookmark :exec
+-- Bookmarking a chirp again moves it to folder_id.
+INSERT INTO
+    bookmarks (user_id, chirp_id, folder_id, created_at)
+VALUES
+    ($1, $2, $3, NOW ())
+ON CONFLICT (user_id, chirp_id) DO UPDATE
+SET folder_id = EXCLUDED.folder_id;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarkedChirps :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg('user_id')
+    AND (sqlc.narg('folder_id')::uuid IS NULL OR bookmarks.folder_id = sqlc.narg('folder_id')::uuid)
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirp_visible_to(chirps, sqlc.arg('user_id'))
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg('user_id')
+    AND chirp_id = ANY (sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: CreateBookmarkFolder :one
+INSERT INTO
+    bookmark_folders (id, user_id, created_at, updated_at, name)
+VALUES
+    (gen_random_uuid (), $1, NOW (), NOW (), $2)
+RETURNING *;
+
+-- name: GetBookmarkFolderByID :one
+SELECT * FROM bookmark_folders
+WHERE id = $1;
+
+-- name: GetBookmarkFolders :many
+SELECT * FROM bookmark_folders
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: CountBookmarkFolders :one
+SELECT COUNT(*) FROM bookmark_folders
+WHERE user_id = $1;
+
+-- name: RenameBookmarkFolder :one
+UPDATE bookmark_folders
+SET name = $3, updated_at = NOW ()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteBookmarkFolder :execrows
+DELETE FROM bookmark_folders
+WHERE id = $1 AND user_id = $2;
\ No newline at end of file
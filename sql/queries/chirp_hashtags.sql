@@ -0,0 +1,77 @@
+-- name: CreateChirpHashtags :exec
+INSERT INTO
+    chirp_hashtags (chirp_id, tag, created_at)
+SELECT
+    sqlc.arg('chirp_id')::uuid,
+    unnest(sqlc.arg('tags')::text[]),
+    sqlc.arg('created_at')::timestamp
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: UpdateChirpHashtagsCreatedAt :exec
+UPDATE chirp_hashtags
+SET created_at = $2
+WHERE chirp_id = $1;
+
+-- name: GetChirpsByHashtag :many
+SELECT chirps.* FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.tag = sqlc.arg('tag')
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (chirp_hashtags.created_at, chirp_hashtags.chirp_id)
+            > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY chirp_hashtags.created_at ASC, chirp_hashtags.chirp_id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsByHashtagDesc :many
+SELECT chirps.* FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.tag = sqlc.arg('tag')
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (chirp_hashtags.created_at, chirp_hashtags.chirp_id)
+            < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY chirp_hashtags.created_at DESC, chirp_hashtags.chirp_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: LockTrendingTagsRefresh :one
+-- Only one instance refreshes the trending list at a time. The lock is
+-- released when the transaction ends.
+SELECT pg_try_advisory_xact_lock(hashtext('trending_tags'))::boolean AS locked;
+
+-- name: DeleteTrendingTags :exec
+DELETE FROM trending_tags;
+
+-- name: ComputeTrendingTags :exec
+INSERT INTO
+    trending_tags (tag, score, chirp_count, computed_at)
+SELECT
+    chirp_hashtags.tag,
+    SUM(POWER(0.5::float8, EXTRACT(EPOCH FROM NOW () - chirp_hashtags.created_at)::float8 / sqlc.arg('half_life_seconds')::float8)),
+    COUNT(*),
+    NOW ()
+FROM chirp_hashtags
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.created_at > NOW () - make_interval(secs => sqlc.arg('window_seconds')::float8)
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirps.visibility = 'public'
+GROUP BY chirp_hashtags.tag
+ORDER BY 2 DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTrendingTags :many
+SELECT * FROM trending_tags
+ORDER BY score DESC
+LIMIT $1;
\ No newline at end of file
@@ -0,0 +1,33 @@
+-- name: CreateChirpMentions :exec
+INSERT INTO
+    chirp_mentions (chirp_id, user_id, start_offset, end_offset)
+SELECT
+    sqlc.arg('chirp_id')::uuid,
+    unnest(sqlc.arg('user_ids')::uuid[]),
+    unnest(sqlc.arg('start_offsets')::integer[]),
+    unnest(sqlc.arg('end_offsets')::integer[]);
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: GetChirpMentions :many
+SELECT * FROM chirp_mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, start_offset;
+
+-- name: GetChirpsMentioningUser :many
+SELECT * FROM chirps
+WHERE tombstoned_at IS NULL
+    AND deleted_at IS NULL
+    AND status = 'published'
+    AND chirp_listed_for(chirps, sqlc.arg('user_id'))
+    AND EXISTS (
+        SELECT 1 FROM chirp_mentions
+        WHERE chirp_mentions.chirp_id = chirps.id
+            AND chirp_mentions.user_id = sqlc.arg('user_id')
+    )
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (published_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
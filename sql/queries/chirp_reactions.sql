@@ -0,0 +1,19 @@
+-- name: CreateChirpReaction :execrows
+INSERT INTO
+    chirp_reactions (chirp_id, user_id, reaction, created_at)
+VALUES
+    ($1, $2, $3, NOW ())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpReaction :execrows
+DELETE FROM chirp_reactions
+WHERE chirp_id = $1 AND user_id = $2 AND reaction = $3;
+
+-- name: GetUserReactionsForChirps :many
+SELECT chirp_id, reaction FROM chirp_reactions
+WHERE user_id = sqlc.arg('user_id')
+    AND chirp_id = ANY (sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: DeleteChirpReactions :exec
+DELETE FROM chirp_reactions
+WHERE chirp_id = $1;
\ No newline at end of file
@@ -1,24 +1,383 @@
 -- name: CreateChirp :one
 INSERT INTO
-    chirps (id, created_at, updated_at, body, user_id)
+    chirps (id, created_at, updated_at, published_at, body, user_id, parent_chirp_id, quoted_chirp_id, status, publish_at, visibility, content_warning, sensitive, expires_at)
 VALUES
-    (gen_random_uuid (), NOW (), NOW (), $1, $2)
+    (gen_random_uuid (), NOW (), NOW (), NOW (), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: CreateRechirp :one
+INSERT INTO
+    chirps (id, created_at, updated_at, published_at, body, user_id, rechirp_of_id)
+VALUES
+    (gen_random_uuid (), NOW (), NOW (), NOW (), '', $1, $2)
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL DO NOTHING
 RETURNING *;
 
 -- name: GetChirps :many
 SELECT * FROM chirps
-ORDER BY created_at ASC;
+WHERE tombstoned_at IS NULL
+    AND deleted_at IS NULL
+    AND status = 'published'
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (published_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY published_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsDesc :many
+SELECT * FROM chirps
+WHERE tombstoned_at IS NULL
+    AND deleted_at IS NULL
+    AND status = 'published'
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (published_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps
-WHERE id = $1;
+WHERE id = sqlc.arg('id')
+    AND deleted_at IS NULL
+    AND chirp_visible_to(chirps, sqlc.narg('viewer_id')::uuid);
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY (sqlc.arg('ids')::uuid[])
+    AND deleted_at IS NULL
+    AND chirp_visible_to(chirps, sqlc.narg('viewer_id')::uuid);
+
+-- name: GetChirpByIDForUpdate :one
+-- Unlike GetChirpByID this also returns trashed chirps, so they can be
+-- restored. Callers changing a chirp in place check deleted_at themselves.
+SELECT * FROM chirps
+WHERE id = $1
+    AND (expires_at IS NULL OR expires_at > NOW ())
+FOR UPDATE;
 
 -- name: GetChirpsByUserID :many
 SELECT * FROM chirps
-WHERE user_id = $1
+WHERE user_id = sqlc.arg('user_id')
+    AND EXISTS (SELECT 1 FROM users WHERE users.id = chirps.user_id)
+    AND tombstoned_at IS NULL
+    AND deleted_at IS NULL
+    AND status = 'published'
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (published_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY published_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsByUserIDDesc :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
     AND EXISTS (SELECT 1 FROM users WHERE users.id = chirps.user_id)
-ORDER BY created_at ASC;
+    AND tombstoned_at IS NULL
+    AND deleted_at IS NULL
+    AND status = 'published'
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (published_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpAncestors :many
+-- Ancestors include trashed chirps, shown as placeholders, so a thread
+-- keeps its shape. So do ones the viewer can't read, flagged as not
+-- visible, so the chain always reaches the real root.
+WITH RECURSIVE
+    ancestors (id, parent_chirp_id, depth) AS (
+        SELECT parent.id, parent.parent_chirp_id, 1
+        FROM chirps parent
+        WHERE parent.id = (SELECT child.parent_chirp_id FROM chirps child WHERE child.id = sqlc.arg('chirp_id'))
+        UNION ALL
+        SELECT parent.id, parent.parent_chirp_id, ancestors.depth + 1
+        FROM chirps parent
+        JOIN ancestors ON parent.id = ancestors.parent_chirp_id
+        WHERE ancestors.depth < sqlc.arg('max_depth')::int
+    )
+SELECT sqlc.embed(chirps), chirp_visible_to(chirps, sqlc.narg('viewer_id')::uuid) AS visible
+FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+ORDER BY ancestors.depth DESC;
+
+-- name: GetChirpDescendants :many
+WITH RECURSIVE
+    descendants (id, depth) AS (
+        SELECT child.id, 1
+        FROM chirps child
+        WHERE child.parent_chirp_id = sqlc.arg('chirp_id')
+        UNION ALL
+        SELECT child.id, descendants.depth + 1
+        FROM chirps child
+        JOIN descendants ON child.parent_chirp_id = descendants.id
+        WHERE descendants.depth < sqlc.arg('max_depth')::int
+    )
+SELECT sqlc.embed(chirps), descendants.depth::int AS depth
+FROM chirps
+JOIN descendants ON chirps.id = descendants.id
+WHERE chirps.status = 'published'
+    AND (chirps.deleted_at IS NULL OR chirps.reply_count > 0)
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (chirps.published_at, chirps.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY chirps.published_at ASC, chirps.id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetHeldChirps :many
+SELECT * FROM chirps
+WHERE status = 'held'
+    AND deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > NOW ())
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: PublishHeldChirp :one
+UPDATE chirps
+SET updated_at = NOW (),
+    status = CASE WHEN publish_at > NOW () THEN 'scheduled' ELSE 'published' END,
+    published_at = CASE WHEN publish_at > NOW () THEN published_at ELSE NOW () END
+WHERE id = $1 AND status = 'held' AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetScheduledChirpsByUserID :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+    AND status = 'scheduled'
+    AND deleted_at IS NULL
+    AND (expires_at IS NULL OR expires_at > NOW ())
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (publish_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY publish_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: RescheduleChirp :one
+UPDATE chirps
+SET updated_at = NOW (), publish_at = $2
+WHERE id = $1 AND status = 'scheduled' AND deleted_at IS NULL
+RETURNING *;
+
+-- name: PublishDueChirps :many
+UPDATE chirps
+SET status = 'published', published_at = NOW (), updated_at = NOW ()
+WHERE id IN (
+        SELECT id FROM chirps
+        WHERE status = 'scheduled' AND publish_at <= NOW () AND deleted_at IS NULL
+            AND (expires_at IS NULL OR expires_at > NOW ())
+        ORDER BY publish_at
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING *;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET updated_at = NOW (), body = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: IncrementChirpReplyCount :exec
+UPDATE chirps
+SET reply_count = reply_count + 1
+WHERE id = $1;
+
+-- name: DecrementChirpReplyCount :exec
+UPDATE chirps
+SET reply_count = reply_count - 1
+WHERE id = $1;
+
+-- name: IncrementChirpRechirpCount :exec
+UPDATE chirps
+SET rechirp_count = rechirp_count + 1
+WHERE id = $1;
+
+-- name: DecrementChirpRechirpCount :exec
+UPDATE chirps
+SET rechirp_count = rechirp_count - 1
+WHERE id = $1;
+
+-- name: IncrementChirpQuoteCount :exec
+UPDATE chirps
+SET quote_count = quote_count + 1
+WHERE id = $1;
+
+-- name: DecrementChirpQuoteCount :exec
+UPDATE chirps
+SET quote_count = quote_count - 1
+WHERE id = $1;
+
+-- name: IncrementChirpReactionCount :exec
+UPDATE chirps
+SET reaction_counts = jsonb_set(
+        reaction_counts,
+        ARRAY[sqlc.arg('reaction')::text],
+        to_jsonb(COALESCE((reaction_counts ->> sqlc.arg('reaction')::text)::int, 0) + 1)
+    )
+WHERE id = sqlc.arg('id');
+
+-- name: DecrementChirpReactionCount :exec
+UPDATE chirps
+SET reaction_counts = CASE
+        WHEN (reaction_counts ->> sqlc.arg('reaction')::text)::int > 1 THEN jsonb_set(
+            reaction_counts,
+            ARRAY[sqlc.arg('reaction')::text],
+            to_jsonb((reaction_counts ->> sqlc.arg('reaction')::text)::int - 1)
+        )
+        ELSE reaction_counts - sqlc.arg('reaction')::text
+    END
+WHERE id = sqlc.arg('id');
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET updated_at = NOW (), body = '', tombstoned_at = NOW (), quoted_chirp_id = NULL, rechirp_count = 0, reaction_counts = '{}', expires_at = NULL
+WHERE id = $1;
+
+-- name: DeleteRechirp :one
+DELETE FROM chirps
+WHERE user_id = $1 AND rechirp_of_id = $2
+RETURNING id;
+
+-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps
+WHERE rechirp_of_id = $1;
+
+-- name: TrashChirp :exec
+UPDATE chirps
+SET deleted_at = NOW ()
+WHERE id = $1;
+
+-- name: HideChirp :exec
+UPDATE chirps
+SET status = 'hidden'
+WHERE id = $1;
+
+-- name: SetChirpContentFlags :one
+UPDATE chirps
+SET content_warning = $2, sensitive = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: DetachQuotedChirp :exec
+UPDATE chirps
+SET quoted_chirp_id = NULL, updated_at = NOW ()
+WHERE id = $1;
+
+-- name: GetTrashedChirpsByUserID :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+    AND deleted_at IS NOT NULL
+    AND tombstoned_at IS NULL
+    AND (expires_at IS NULL OR expires_at > NOW ())
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (deleted_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY deleted_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsToPurge :many
+SELECT * FROM chirps
+WHERE deleted_at < $1 AND tombstoned_at IS NULL
+ORDER BY deleted_at
+LIMIT $2
+FOR UPDATE SKIP LOCKED;
+
+-- name: GetExpiredChirps :many
+SELECT * FROM chirps
+WHERE expires_at <= NOW () AND tombstoned_at IS NULL
+ORDER BY expires_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: GetAutoDeleteChirps :many
+-- Chirps past their author's auto_delete_after_days. Scheduled and held
+-- chirps haven't been out yet, and trashed ones are left to the trash purge.
+SELECT chirps.* FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE users.auto_delete_after_days IS NOT NULL
+    AND chirps.published_at < NOW () - make_interval(days => users.auto_delete_after_days)
+    AND chirps.status IN ('published', 'hidden')
+    AND chirps.deleted_at IS NULL
+    AND chirps.tombstoned_at IS NULL
+ORDER BY chirps.published_at
+LIMIT $1
+FOR UPDATE OF chirps SKIP LOCKED;
+
+-- name: ChirpHasReplies :one
+-- Counts replies in any state, scheduled and held ones included, so that a
+-- chirp they're waiting to reply to is tombstoned rather than deleted.
+SELECT EXISTS (
+    SELECT 1 FROM chirps
+    WHERE parent_chirp_id = $1
+);
 
 -- name: DeleteChirpByID :exec
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: DeleteUnrepliedTombstone :one
+-- Deletes a tombstone that nothing replies to any more, returning its
+-- parent so the caller can check that in turn.
+DELETE FROM chirps
+WHERE id = $1
+    AND tombstoned_at IS NOT NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM chirps replies
+        WHERE replies.parent_chirp_id = chirps.id
+    )
+RETURNING parent_chirp_id;
+
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps),
+    ts_rank_cd(chirps.search_vector, query)::real AS rank,
+    chirp_search_snippet(chirps.body, query) AS snippet
+FROM chirps, to_tsquery('english', sqlc.arg('query')) query
+WHERE chirps.search_vector @@ query
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')::uuid)
+    AND (sqlc.narg('cursor_rank')::real IS NULL
+        OR (ts_rank_cd(chirps.search_vector, query), chirps.published_at, chirps.id)
+            < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY rank DESC, chirps.published_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: SearchChirpsByDate :many
+SELECT sqlc.embed(chirps),
+    ts_rank_cd(chirps.search_vector, query)::real AS rank,
+    chirp_search_snippet(chirps.body, query) AS snippet
+FROM chirps, to_tsquery('english', sqlc.arg('query')) query
+WHERE chirps.search_vector @@ query
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')::uuid)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (chirps.published_at, chirps.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY chirps.published_at ASC, chirps.id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: SearchChirpsByDateDesc :many
+SELECT sqlc.embed(chirps),
+    ts_rank_cd(chirps.search_vector, query)::real AS rank,
+    chirp_search_snippet(chirps.body, query) AS snippet
+FROM chirps, to_tsquery('english', sqlc.arg('query')) query
+WHERE chirps.search_vector @@ query
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')::uuid)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (chirps.published_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY chirps.published_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
@@ -0,0 +1,64 @@
+-- name: CreateDraft :one
+INSERT INTO
+    drafts (id, created_at, updated_at, user_id, body, in_reply_to, quoted_chirp_id, media, publish_at, visibility, content_warning, sensitive)
+VALUES
+    (gen_random_uuid (), NOW (), NOW (), $1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetDraftByID :one
+SELECT * FROM drafts
+WHERE id = $1;
+
+-- name: GetDraftByIDForUpdate :one
+SELECT * FROM drafts
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetDraftsByUserID :many
+SELECT * FROM drafts
+WHERE user_id = sqlc.arg('user_id')
+    AND (deleted_at IS NULL OR sqlc.arg('include_deleted')::boolean)
+    AND change_seq > sqlc.arg('after_seq')
+ORDER BY change_seq ASC
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET
+    updated_at = NOW (),
+    change_seq = nextval('draft_change_seq'),
+    version = version + 1,
+    body = sqlc.arg('body'),
+    in_reply_to = sqlc.narg('in_reply_to'),
+    quoted_chirp_id = sqlc.narg('quoted_chirp_id'),
+    media = sqlc.arg('media'),
+    publish_at = sqlc.narg('publish_at'),
+    visibility = sqlc.arg('visibility'),
+    content_warning = sqlc.arg('content_warning'),
+    sensitive = sqlc.arg('sensitive')
+WHERE id = sqlc.arg('id')
+    AND user_id = sqlc.arg('user_id')
+    AND version = sqlc.arg('version')
+    AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+UPDATE drafts
+SET
+    updated_at = NOW (),
+    change_seq = nextval('draft_change_seq'),
+    deleted_at = NOW (),
+    version = version + 1,
+    body = '',
+    in_reply_to = NULL,
+    quoted_chirp_id = NULL,
+    media = '[]',
+    publish_at = NULL,
+    visibility = 'public',
+    content_warning = '',
+    sensitive = FALSE
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: PurgeDeletedDrafts :execrows
+DELETE FROM drafts
+WHERE deleted_at < $1;
\ No newline at end of file
@@ -0,0 +1,68 @@
+-- name: CreateFollow :execrows
+INSERT INTO
+    follows (follower_id, followee_id, created_at)
+VALUES
+    ($1, $2, NOW ())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: IncrementFollowCounts :exec
+UPDATE users
+SET
+    following_count = following_count + CASE WHEN id = sqlc.arg('follower_id') THEN 1 ELSE 0 END,
+    follower_count = follower_count + CASE WHEN id = sqlc.arg('followee_id') THEN 1 ELSE 0 END
+WHERE id IN (sqlc.arg('follower_id'), sqlc.arg('followee_id'));
+
+-- name: DecrementFollowCounts :exec
+UPDATE users
+SET
+    following_count = following_count - CASE WHEN id = sqlc.arg('follower_id') THEN 1 ELSE 0 END,
+    follower_count = follower_count - CASE WHEN id = sqlc.arg('followee_id') THEN 1 ELSE 0 END
+WHERE id IN (sqlc.arg('follower_id'), sqlc.arg('followee_id'));
+
+-- name: GetFollowers :many
+SELECT users.id, users.handle, follows.created_at FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg('user_id')
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (follows.created_at, follows.follower_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY follows.created_at DESC, follows.follower_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetFollowing :many
+SELECT users.id, users.handle, follows.created_at FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (follows.created_at, follows.followee_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY follows.created_at DESC, follows.followee_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetHomeTimeline :many
+-- Fetches the newest page from each author separately, using their
+-- (user_id, published_at, id) index, and merges those. However many
+-- accounts the user follows, no author contributes more than one page of
+-- rows.
+SELECT timeline.* FROM (
+        SELECT sqlc.arg('user_id')::uuid AS author_id
+        UNION ALL
+        SELECT follows.followee_id FROM follows
+        WHERE follows.follower_id = sqlc.arg('user_id')
+    ) authors
+CROSS JOIN LATERAL (
+        SELECT * FROM chirps
+        WHERE chirps.user_id = authors.author_id
+            AND chirps.tombstoned_at IS NULL
+            AND chirps.deleted_at IS NULL
+            AND chirps.status = 'published'
+            AND chirp_listed_for(chirps, sqlc.arg('user_id'))
+            AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+                OR (chirps.published_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+        ORDER BY chirps.published_at DESC, chirps.id DESC
+        LIMIT sqlc.arg('limit')
+    ) timeline
+ORDER BY timeline.published_at DESC, timeline.id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
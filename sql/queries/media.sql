@@ -0,0 +1,63 @@
+-- name: CreateMedia :one
+INSERT INTO
+    media (id, created_at, user_id, content_type, size_bytes, width, height, storage_key, thumbnail_key, thumbnail_content_type)
+VALUES
+    ($1, NOW (), $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetMediaByID :one
+SELECT * FROM media
+WHERE id = $1;
+
+-- name: GetMediaByIDs :many
+SELECT * FROM media
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: CreateChirpMedia :exec
+INSERT INTO
+    chirp_media (chirp_id, media_id, position, alt_text)
+SELECT
+    sqlc.arg('chirp_id')::uuid,
+    unnest(sqlc.arg('media_ids')::uuid[]),
+    unnest(sqlc.arg('positions')::integer[]),
+    unnest(sqlc.arg('alt_texts')::text[]);
+
+-- name: GetChirpMedia :many
+SELECT chirp_media.chirp_id, chirp_media.alt_text, sqlc.embed(media) FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_media.chirp_id, chirp_media.position;
+
+-- name: DeleteChirpMedia :many
+-- Media is only ever attached to one chirp, so it goes with the chirp. The
+-- files are left for the caller to delete once the transaction commits.
+DELETE FROM media
+WHERE id IN (
+    SELECT media_id FROM chirp_media
+    WHERE chirp_id = $1
+)
+RETURNING storage_key, thumbnail_key;
+
+-- name: GetMediaForViewer :one
+-- The uploader can always see their media, attached or not. Anyone else
+-- needs to be able to see a chirp it's attached to. Public is set when an
+-- anonymous reader could see it too and it isn't set to expire, so shared
+-- caches may keep it.
+SELECT sqlc.embed(media), EXISTS (
+        SELECT 1 FROM chirp_media
+        JOIN chirps ON chirps.id = chirp_media.chirp_id
+        WHERE chirp_media.media_id = media.id
+            AND chirps.deleted_at IS NULL
+            AND chirps.expires_at IS NULL
+            AND chirp_visible_to(chirps, NULL::uuid)
+    )::boolean AS public
+FROM media
+WHERE media.id = sqlc.arg('id')
+    AND (media.user_id = sqlc.narg('viewer_id')::uuid
+        OR EXISTS (
+            SELECT 1 FROM chirp_media
+            JOIN chirps ON chirps.id = chirp_media.chirp_id
+            WHERE chirp_media.media_id = media.id
+                AND chirps.deleted_at IS NULL
+                AND chirp_visible_to(chirps, sqlc.narg('viewer_id')::uuid)
+        ));
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- name: CreateModerationAction :one
+INSERT INTO
+    moderation_actions (id, created_at, moderator_id, report_id, action, reason, target_user_id, target_chirp_id)
+VALUES
+    (gen_random_uuid (), NOW (), $1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetModerationActions :many
+SELECT * FROM moderation_actions
+WHERE (sqlc.narg('target_user_id')::uuid IS NULL OR target_user_id = sqlc.narg('target_user_id')::uuid)
+    AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action')::text)
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
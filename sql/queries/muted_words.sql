@@ -0,0 +1,30 @@
+-- name: UpsertMutedWord :one
+INSERT INTO
+    muted_words (id, user_id, created_at, phrase, expires_at)
+VALUES
+    (gen_random_uuid (), $1, NOW (), $2, $3)
+ON CONFLICT (user_id, phrase) DO UPDATE
+SET expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: GetMutedWordsByUserID :many
+SELECT * FROM muted_words
+WHERE user_id = $1
+    AND (expires_at IS NULL OR expires_at > NOW ())
+ORDER BY created_at, id;
+
+-- name: CountOtherMutedWords :one
+-- Counts the user's live rules other than phrase, so re-muting a phrase
+-- to change its expiry still works once the user is at the limit.
+SELECT COUNT(*) FROM muted_words
+WHERE user_id = $1
+    AND phrase <> $2
+    AND (expires_at IS NULL OR expires_at > NOW ());
+
+-- name: DeleteMutedWord :execrows
+DELETE FROM muted_words
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteExpiredMutedWords :execrows
+DELETE FROM muted_words
+WHERE expires_at <= NOW ();
\ No newline at end of file
@@ -0,0 +1,46 @@
+-- name: PinChirp :execrows
+INSERT INTO
+    pinned_chirps (user_id, chirp_id, position, created_at)
+SELECT
+    sqlc.arg('user_id'),
+    sqlc.arg('chirp_id'),
+    COALESCE(MAX(position), 0) + 1,
+    NOW ()
+FROM pinned_chirps
+WHERE user_id = sqlc.arg('user_id')
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnpinChirp :execrows
+DELETE FROM pinned_chirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: DeleteChirpPins :exec
+DELETE FROM pinned_chirps
+WHERE chirp_id = $1;
+
+-- name: CountPinnedChirps :one
+SELECT COUNT(*) FROM pinned_chirps
+WHERE user_id = $1;
+
+-- name: GetPinnedChirpIDs :many
+SELECT chirp_id FROM pinned_chirps
+WHERE user_id = $1
+ORDER BY position;
+
+-- name: ReorderPinnedChirps :exec
+-- Moves each pin to its place in chirp_ids, counting from 1.
+UPDATE pinned_chirps
+SET position = ordering.position
+FROM unnest(sqlc.arg('chirp_ids')::uuid[]) WITH ORDINALITY AS ordering (chirp_id, position)
+WHERE pinned_chirps.user_id = sqlc.arg('user_id')
+    AND pinned_chirps.chirp_id = ordering.chirp_id;
+
+-- name: GetPinnedChirps :many
+SELECT chirps.* FROM pinned_chirps
+JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = sqlc.arg('user_id')
+    AND chirps.tombstoned_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirp_listed_for(chirps, sqlc.narg('viewer_id')::uuid)
+ORDER BY pinned_chirps.position;
\ No newline at end of file
@@ -0,0 +1,72 @@
+-- name: CreatePoll :one
+INSERT INTO
+    polls (chirp_id, created_at, closes_at)
+VALUES
+    ($1, NOW (), $2)
+RETURNING *;
+
+-- name: CreatePollOption :exec
+INSERT INTO
+    poll_options (chirp_id, position, text)
+VALUES
+    ($1, $2, $3);
+
+-- name: GetPollByChirpIDForUpdate :one
+SELECT * FROM polls
+WHERE chirp_id = $1
+FOR UPDATE;
+
+-- name: GetPollsForChirps :many
+SELECT * FROM polls
+WHERE chirp_id = ANY (sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetPollOptionsForChirps :many
+SELECT * FROM poll_options
+WHERE chirp_id = ANY (sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: GetUserPollVotesForChirps :many
+SELECT chirp_id, position FROM poll_votes
+WHERE user_id = sqlc.arg('user_id')
+    AND chirp_id = ANY (sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: CreatePollVote :exec
+INSERT INTO
+    poll_votes (chirp_id, user_id, position, created_at)
+VALUES
+    ($1, $2, $3, NOW ());
+
+-- name: IncrementPollOptionVoteCount :exec
+UPDATE poll_options
+SET vote_count = vote_count + 1
+WHERE poll_options.chirp_id = $1 AND poll_options.position = $2;
+
+-- name: IncrementPollVoteCount :exec
+UPDATE polls
+SET vote_count = vote_count + 1
+WHERE chirp_id = $1;
+
+-- name: GetPollsToFinalize :many
+SELECT chirp_id FROM polls
+WHERE finalized_at IS NULL AND closes_at <= NOW ()
+ORDER BY closes_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: RecountPollOptionVotes :exec
+UPDATE poll_options
+SET vote_count = (
+        SELECT COUNT(*) FROM poll_votes
+        WHERE poll_votes.chirp_id = poll_options.chirp_id
+            AND poll_votes.position = poll_options.position
+    )
+WHERE chirp_id = ANY (sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: FinalizePolls :exec
+UPDATE polls
+SET finalized_at = NOW (),
+    vote_count = (
+        SELECT COUNT(*) FROM poll_votes
+        WHERE poll_votes.chirp_id = polls.chirp_id
+    )
+WHERE chirp_id = ANY (sqlc.arg('chirp_ids')::uuid[]);
\ No newline at end of file
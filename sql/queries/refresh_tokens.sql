@@ -24,4 +24,9 @@ WHERE token = $1 AND expires_at > NOW () AND revoked_at IS NULL;
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
 SET updated_at = NOW (), revoked_at = NOW ()
-WHERE token = $1;
\ No newline at end of file
+WHERE token = $1;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET updated_at = NOW (), revoked_at = NOW ()
+WHERE user_id = $1 AND revoked_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,50 @@
+-- name: CreateReport :one
+INSERT INTO
+    reports (id, created_at, updated_at, reporter_id, reported_user_id, chirp_id, chirp_body, reason, details)
+VALUES
+    (gen_random_uuid (), NOW (), NOW (), $1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetReportByID :one
+SELECT * FROM reports
+WHERE id = $1;
+
+-- name: GetReportByIDForUpdate :one
+SELECT * FROM reports
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetReportsByReporterID :many
+SELECT * FROM reports
+WHERE reporter_id = sqlc.arg('reporter_id')
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetReportsByStatus :many
+SELECT * FROM reports
+WHERE status = ANY(sqlc.arg('statuses')::text[])
+    AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ClaimReport :one
+UPDATE reports
+SET status = 'claimed', claimed_by = $2, claimed_at = NOW (), updated_at = NOW ()
+WHERE id = $1
+RETURNING *;
+
+-- name: ResolveReport :one
+UPDATE reports
+SET
+    status = 'resolved',
+    claimed_by = sqlc.arg('moderator_id'),
+    claimed_at = COALESCE(claimed_at, NOW ()),
+    resolution = sqlc.arg('resolution'),
+    resolution_reason = sqlc.arg('resolution_reason'),
+    resolved_at = NOW (),
+    updated_at = NOW ()
+WHERE id = sqlc.arg('id')
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,50 @@
+-- name: CreateSubscription :one
+INSERT INTO
+    subscriptions (id, user_id, created_at, updated_at, plan, status, current_period_start, current_period_end, trial_ends_at, grace_period_ends_at)
+VALUES
+    (gen_random_uuid (), $1, NOW (), NOW (), $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetCurrentSubscriptionByUserID :one
+SELECT * FROM subscriptions
+WHERE user_id = $1 AND status <> 'expired';
+
+-- name: GetCurrentSubscriptionByUserIDForUpdate :one
+SELECT * FROM subscriptions
+WHERE user_id = $1 AND status <> 'expired'
+FOR UPDATE;
+
+-- name: UpdateSubscription :one
+UPDATE subscriptions
+SET updated_at = NOW (),
+    status = $2,
+    current_period_start = $3,
+    current_period_end = $4,
+    trial_ends_at = $5,
+    grace_period_ends_at = $6,
+    canceled_at = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: ExpireLapsedSubscriptions :many
+-- Must agree with subscription.Subscription.EntitledAt.
+UPDATE subscriptions
+SET status = 'expired', ended_at = NOW (), updated_at = NOW ()
+WHERE id IN (
+        SELECT id FROM subscriptions
+        WHERE status NOT IN ('expired', 'lifetime')
+            AND NOW () >= CASE
+                WHEN status = 'canceled' THEN current_period_end
+                ELSE grace_period_ends_at
+            END
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING *;
+
+-- name: RecordPolkaEvent :execrows
+INSERT INTO
+    polka_events (id, user_id, event, received_at)
+VALUES
+    ($1, $2, $3, NOW ())
+ON CONFLICT (id) DO NOTHING;
\ No newline at end of file
@@ -1,8 +1,8 @@
 -- name: CreateUser :one
 INSERT INTO
-    users (id, created_at, updated_at, email, hashed_password)
+    users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES
-    (gen_random_uuid (), NOW (), NOW (), $1, $2)
+    (gen_random_uuid (), NOW (), NOW (), $1, $2, $3)
 RETURNING *;
 
 -- name: DeleteAllUsers :exec
@@ -12,18 +12,60 @@ DELETE FROM users;
 SELECT * FROM users
 WHERE email = $1;
 
--- name: UpdateUser :one
-UPDATE users
-SET updated_at = NOW (), email = $2, hashed_password = $3
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserByIDForUpdate :one
+SELECT * FROM users
 WHERE id = $1
-RETURNING *;
+FOR UPDATE;
 
--- name: UpgradeUserToChirpyRed :exec
+-- name: GetUsersByHandlesOrEmails :many
+-- An email only matches a user who'll be able to read the chirp once it's
+-- published, not counting the mention itself, so mentions can't be used
+-- to find out whose address it is.
+SELECT * FROM users
+WHERE handle = ANY(sqlc.arg('handles')::text[])
+    OR (
+        LOWER(email) = ANY(sqlc.arg('emails')::text[])
+        AND EXISTS (
+            SELECT 1 FROM chirps
+            WHERE chirps.id = sqlc.arg('chirp_id')
+                AND chirp_visible_to(
+                    jsonb_populate_record(chirps, to_jsonb(chirps) || '{"status": "published"}'),
+                    users.id
+                )
+        )
+    );
+
+-- name: UpdateUser :one
 UPDATE users
-SET is_chirpy_red = TRUE
-WHERE id = $1;
+SET
+    updated_at = NOW (),
+    email = sqlc.arg('email'),
+    hashed_password = sqlc.arg('hashed_password'),
+    handle = COALESCE(sqlc.narg('handle'), handle)
+WHERE id = sqlc.arg('id')
+RETURNING *;
 
 -- name: UserExists :one
 SELECT EXISTS(
     SELECT 1 FROM users WHERE id = $1
-);
\ No newline at end of file
+);
+
+-- name: IsUserSuspended :one
+SELECT EXISTS(
+    SELECT 1 FROM users WHERE id = $1 AND suspended_at IS NOT NULL
+);
+
+-- name: SuspendUser :exec
+UPDATE users
+SET suspended_at = COALESCE(suspended_at, NOW ()), updated_at = NOW ()
+WHERE id = $1;
+
+-- name: UpdateUserPreferences :one
+UPDATE users
+SET expand_sensitive = $2, auto_delete_after_days = $3, updated_at = NOW ()
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE
+    chirp_revisions (
+        id UUID PRIMARY KEY,
+        created_at TIMESTAMP NOT NULL,
+        chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+        body TEXT NOT NULL
+    );
+
+CREATE INDEX chirp_revisions_chirp_id_created_at_idx ON chirp_revisions (chirp_id, created_at);
+
+-- +goose Down
+DROP TABLE chirp_revisions;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN parent_chirp_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN tombstoned_at TIMESTAMP;
+
+CREATE INDEX chirps_parent_chirp_id_created_at_id_idx ON chirps (parent_chirp_id, created_at, id);
+
+-- +goose Down
+DROP INDEX chirps_parent_chirp_id_created_at_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN tombstoned_at,
+DROP COLUMN reply_count,
+DROP COLUMN parent_chirp_id;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE
+    chirp_reactions (
+        chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        reaction TEXT NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (chirp_id, user_id, reaction)
+    );
+
+CREATE INDEX chirp_reactions_user_id_chirp_id_idx ON chirp_reactions (user_id, chirp_id);
+
+ALTER TABLE chirps
+ADD COLUMN reaction_counts JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN reaction_counts;
+
+DROP TABLE chirp_reactions;
\ No newline at end of file
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE
+    chirp_hashtags (
+        chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+        tag TEXT NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (chirp_id, tag)
+    );
+
+CREATE INDEX chirp_hashtags_tag_created_at_chirp_id_idx ON chirp_hashtags (tag, created_at, chirp_id);
+
+CREATE INDEX chirp_hashtags_created_at_idx ON chirp_hashtags (created_at);
+
+CREATE TABLE
+    trending_tags (
+        tag TEXT PRIMARY KEY,
+        score DOUBLE PRECISION NOT NULL,
+        chirp_count INTEGER NOT NULL,
+        computed_at TIMESTAMP NOT NULL
+    );
+
+-- +goose Down
+DROP TABLE trending_tags;
+
+DROP TABLE chirp_hashtags;
\ No newline at end of file
@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE
+    banned_words (
+        word TEXT PRIMARY KEY,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL,
+        action TEXT NOT NULL CHECK (action IN ('mask', 'hold', 'reject'))
+    );
+
+INSERT INTO
+    banned_words (word, created_at, updated_at, action)
+VALUES
+    ('kerfuffle', NOW (), NOW (), 'mask'),
+    ('sharbert', NOW (), NOW (), 'mask'),
+    ('fornax', NOW (), NOW (), 'mask');
+
+ALTER TABLE chirps
+ADD COLUMN status TEXT NOT NULL DEFAULT 'published' CHECK (status IN ('published', 'held'));
+
+CREATE INDEX chirps_held_created_at_id_idx ON chirps (created_at, id)
+WHERE status = 'held';
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN status;
+
+DROP TABLE banned_words;
+
+ALTER TABLE users
+DROP COLUMN is_admin;
\ No newline at end of file
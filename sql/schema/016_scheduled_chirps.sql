@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN publish_at TIMESTAMP;
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_status_check,
+ADD CONSTRAINT chirps_status_check CHECK (status IN ('published', 'held', 'scheduled'));
+
+CREATE INDEX chirps_scheduled_publish_at_idx ON chirps (publish_at)
+WHERE status = 'scheduled';
+
+-- +goose Down
+DELETE FROM chirps
+WHERE status = 'scheduled';
+
+DROP INDEX chirps_scheduled_publish_at_idx;
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_status_check,
+ADD CONSTRAINT chirps_status_check CHECK (status IN ('published', 'held'));
+
+ALTER TABLE chirps
+DROP COLUMN publish_at;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE
+    drafts (
+        id UUID PRIMARY KEY,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL,
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        body TEXT NOT NULL,
+        -- Not foreign keys: a draft can outlive the chirp it answers, and
+        -- publishing checks the targets again.
+        in_reply_to UUID,
+        quoted_chirp_id UUID,
+        media JSONB NOT NULL DEFAULT '[]',
+        publish_at TIMESTAMP,
+        version INTEGER NOT NULL DEFAULT 1,
+        deleted_at TIMESTAMP
+    );
+
+CREATE INDEX drafts_user_id_updated_at_idx ON drafts (user_id, updated_at, id);
+
+-- +goose Down
+DROP TABLE drafts;
\ No newline at end of file
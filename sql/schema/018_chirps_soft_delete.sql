@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX chirps_user_id_deleted_at_id_idx ON chirps (user_id, deleted_at, id)
+WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX chirps_trash_deleted_at_idx ON chirps (deleted_at)
+WHERE deleted_at IS NOT NULL AND tombstoned_at IS NULL;
+
+-- +goose Down
+DELETE FROM chirps
+WHERE deleted_at IS NOT NULL AND tombstoned_at IS NULL;
+
+DROP INDEX chirps_trash_deleted_at_idx;
+
+DROP INDEX chirps_user_id_deleted_at_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN deleted_at;
\ No newline at end of file
@@ -0,0 +1,34 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'followers', 'mentioned', 'private'));
+
+ALTER TABLE drafts
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'followers', 'mentioned', 'private'));
+
+-- chirp_visible_to decides who may read a chirp. Authors see all their own
+-- chirps; anyone else only published ones their visibility allows. There's
+-- no follow graph yet, so followers-only chirps reach the accounts they
+-- mention, as mentioned-only ones do.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible_to (chirp chirps, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT (viewer_id IS NOT NULL AND chirp.user_id = viewer_id)
+        OR (chirp.status = 'published' AND (
+            chirp.visibility = 'public'
+            OR (chirp.visibility IN ('followers', 'mentioned') AND EXISTS (
+                SELECT 1 FROM chirp_mentions
+                WHERE chirp_mentions.chirp_id = chirp.id
+                    AND chirp_mentions.user_id = viewer_id
+            ))
+        ))
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible_to;
+
+ALTER TABLE drafts
+DROP COLUMN visibility;
+
+ALTER TABLE chirps
+DROP COLUMN visibility;
\ No newline at end of file
@@ -0,0 +1,60 @@
+-- +goose Up
+CREATE TABLE
+    follows (
+        follower_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        followee_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        created_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (follower_id, followee_id),
+        CHECK (follower_id <> followee_id)
+    );
+
+CREATE INDEX follows_follower_id_created_at_idx ON follows (follower_id, created_at, followee_id);
+
+CREATE INDEX follows_followee_id_created_at_idx ON follows (followee_id, created_at, follower_id);
+
+ALTER TABLE users
+ADD COLUMN follower_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN following_count INTEGER NOT NULL DEFAULT 0;
+
+-- Followers-only chirps now reach followers as well as mentioned accounts.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to (chirp chirps, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT (viewer_id IS NOT NULL AND chirp.user_id = viewer_id)
+        OR (chirp.status = 'published' AND (
+            chirp.visibility = 'public'
+            OR (chirp.visibility = 'followers' AND EXISTS (
+                SELECT 1 FROM follows
+                WHERE follows.follower_id = viewer_id
+                    AND follows.followee_id = chirp.user_id
+            ))
+            OR (chirp.visibility IN ('followers', 'mentioned') AND EXISTS (
+                SELECT 1 FROM chirp_mentions
+                WHERE chirp_mentions.chirp_id = chirp.id
+                    AND chirp_mentions.user_id = viewer_id
+            ))
+        ))
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to (chirp chirps, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT (viewer_id IS NOT NULL AND chirp.user_id = viewer_id)
+        OR (chirp.status = 'published' AND (
+            chirp.visibility = 'public'
+            OR (chirp.visibility IN ('followers', 'mentioned') AND EXISTS (
+                SELECT 1 FROM chirp_mentions
+                WHERE chirp_mentions.chirp_id = chirp.id
+                    AND chirp_mentions.user_id = viewer_id
+            ))
+        ))
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE users
+DROP COLUMN following_count,
+DROP COLUMN follower_count;
+
+DROP TABLE follows;
\ No newline at end of file
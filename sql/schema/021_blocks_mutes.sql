@@ -0,0 +1,102 @@
+-- +goose Up
+CREATE TABLE
+    blocks (
+        blocker_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        blocked_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        created_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (blocker_id, blocked_id),
+        CHECK (blocker_id <> blocked_id)
+    );
+
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id, blocker_id);
+
+CREATE TABLE
+    mutes (
+        muter_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        muted_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        created_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (muter_id, muted_id),
+        CHECK (muter_id <> muted_id)
+    );
+
+-- A block works both ways: neither side can read the other's chirps.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to (chirp chirps, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT (viewer_id IS NOT NULL AND chirp.user_id = viewer_id)
+        OR (chirp.status = 'published'
+            AND NOT EXISTS (
+                SELECT 1 FROM blocks
+                WHERE (blocks.blocker_id = chirp.user_id AND blocks.blocked_id = viewer_id)
+                    OR (blocks.blocker_id = viewer_id AND blocks.blocked_id = chirp.user_id)
+            )
+            AND (
+                chirp.visibility = 'public'
+                OR (chirp.visibility = 'followers' AND EXISTS (
+                    SELECT 1 FROM follows
+                    WHERE follows.follower_id = viewer_id
+                        AND follows.followee_id = chirp.user_id
+                ))
+                OR (chirp.visibility IN ('followers', 'mentioned') AND EXISTS (
+                    SELECT 1 FROM chirp_mentions
+                    WHERE chirp_mentions.chirp_id = chirp.id
+                        AND chirp_mentions.user_id = viewer_id
+                ))
+            ))
+$$;
+-- +goose StatementEnd
+
+-- chirp_listed_for decides whether a chirp belongs in a list the viewer asked
+-- for. On top of chirp_visible_to, it leaves out chirps by accounts the
+-- viewer muted, and rechirps of chirps by muted or blocked accounts.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_listed_for (chirp chirps, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT chirp_visible_to(chirp, viewer_id)
+        AND NOT EXISTS (
+            SELECT 1 FROM mutes
+            WHERE mutes.muter_id = viewer_id
+                AND mutes.muted_id = chirp.user_id
+        )
+        AND NOT EXISTS (
+            SELECT 1 FROM chirps original
+            WHERE original.id = chirp.rechirp_of_id
+                AND EXISTS (
+                    SELECT 1 FROM mutes
+                    WHERE mutes.muter_id = viewer_id
+                        AND mutes.muted_id = original.user_id
+                    UNION ALL
+                    SELECT 1 FROM blocks
+                    WHERE (blocks.blocker_id = viewer_id AND blocks.blocked_id = original.user_id)
+                        OR (blocks.blocker_id = original.user_id AND blocks.blocked_id = viewer_id)
+                )
+        )
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_listed_for;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to (chirp chirps, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT (viewer_id IS NOT NULL AND chirp.user_id = viewer_id)
+        OR (chirp.status = 'published' AND (
+            chirp.visibility = 'public'
+            OR (chirp.visibility = 'followers' AND EXISTS (
+                SELECT 1 FROM follows
+                WHERE follows.follower_id = viewer_id
+                    AND follows.followee_id = chirp.user_id
+            ))
+            OR (chirp.visibility IN ('followers', 'mentioned') AND EXISTS (
+                SELECT 1 FROM chirp_mentions
+                WHERE chirp_mentions.chirp_id = chirp.id
+                    AND chirp_mentions.user_id = viewer_id
+            ))
+        ))
+$$;
+-- +goose StatementEnd
+
+DROP TABLE mutes;
+
+DROP TABLE blocks;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE
+    muted_words (
+        id UUID PRIMARY KEY,
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        created_at TIMESTAMP NOT NULL,
+        phrase TEXT NOT NULL,
+        expires_at TIMESTAMP,
+        UNIQUE (user_id, phrase)
+    );
+
+CREATE INDEX muted_words_expires_at_idx ON muted_words (expires_at)
+WHERE
+    expires_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE muted_words;
\ No newline at end of file
@@ -0,0 +1,90 @@
+-- +goose Up
+CREATE TABLE
+    reports (
+        id UUID PRIMARY KEY,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL,
+        reporter_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        reported_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        chirp_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+        chirp_body TEXT,
+        reason TEXT NOT NULL CHECK (
+            reason IN (
+                'spam',
+                'harassment',
+                'hate',
+                'violence',
+                'self_harm',
+                'sexual',
+                'impersonation',
+                'other'
+            )
+        ),
+        details TEXT NOT NULL DEFAULT '',
+        status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'claimed', 'resolved')),
+        claimed_by UUID REFERENCES users (id) ON DELETE SET NULL,
+        claimed_at TIMESTAMP,
+        resolution TEXT,
+        resolution_reason TEXT,
+        resolved_at TIMESTAMP
+    );
+
+CREATE INDEX reports_status_created_at_idx ON reports (status, created_at, id);
+
+CREATE INDEX reports_reporter_id_created_at_idx ON reports (reporter_id, created_at, id);
+
+-- One unresolved report per reporter and target. A user report has no
+-- chirp, so the nil UUID stands in for it.
+CREATE UNIQUE INDEX reports_unresolved_target_idx ON reports (
+    reporter_id,
+    reported_user_id,
+    COALESCE(chirp_id, '00000000-0000-0000-0000-000000000000')
+)
+WHERE
+    status <> 'resolved';
+
+CREATE TABLE
+    moderation_actions (
+        id UUID PRIMARY KEY,
+        created_at TIMESTAMP NOT NULL,
+        moderator_id UUID REFERENCES users (id) ON DELETE SET NULL,
+        report_id UUID REFERENCES reports (id) ON DELETE SET NULL,
+        action TEXT NOT NULL CHECK (
+            action IN (
+                'dismiss',
+                'hide_chirp',
+                'delete_chirp',
+                'warn_user',
+                'suspend_user'
+            )
+        ),
+        reason TEXT NOT NULL,
+        target_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        -- Not a foreign key: deleting the chirp is one of the actions.
+        target_chirp_id UUID
+    );
+
+CREATE INDEX moderation_actions_target_user_id_idx ON moderation_actions (target_user_id, created_at, id);
+
+ALTER TABLE users
+ADD COLUMN suspended_at TIMESTAMP;
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_status_check,
+ADD CONSTRAINT chirps_status_check CHECK (status IN ('published', 'held', 'scheduled', 'hidden'));
+
+-- +goose Down
+UPDATE chirps
+SET status = 'held'
+WHERE status = 'hidden';
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_status_check,
+ADD CONSTRAINT chirps_status_check CHECK (status IN ('published', 'held', 'scheduled'));
+
+ALTER TABLE users
+DROP COLUMN suspended_at;
+
+DROP TABLE moderation_actions;
+
+DROP TABLE reports;
\ No newline at end of file
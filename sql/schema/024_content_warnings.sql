@@ -0,0 +1,51 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN content_warning TEXT NOT NULL DEFAULT '',
+ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE drafts
+ADD COLUMN content_warning TEXT NOT NULL DEFAULT '',
+ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE users
+ADD COLUMN expand_sensitive BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE moderation_actions
+DROP CONSTRAINT moderation_actions_action_check,
+ADD CONSTRAINT moderation_actions_action_check CHECK (
+    action IN (
+        'dismiss',
+        'hide_chirp',
+        'delete_chirp',
+        'warn_user',
+        'suspend_user',
+        'flag_chirp'
+    )
+);
+
+-- +goose Down
+DELETE FROM moderation_actions
+WHERE action = 'flag_chirp';
+
+ALTER TABLE moderation_actions
+DROP CONSTRAINT moderation_actions_action_check,
+ADD CONSTRAINT moderation_actions_action_check CHECK (
+    action IN (
+        'dismiss',
+        'hide_chirp',
+        'delete_chirp',
+        'warn_user',
+        'suspend_user'
+    )
+);
+
+ALTER TABLE users
+DROP COLUMN expand_sensitive;
+
+ALTER TABLE drafts
+DROP COLUMN content_warning,
+DROP COLUMN sensitive;
+
+ALTER TABLE chirps
+DROP COLUMN content_warning,
+DROP COLUMN sensitive;
\ No newline at end of file
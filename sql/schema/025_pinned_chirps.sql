@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE
+    pinned_chirps (
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+        position INTEGER NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (user_id, chirp_id),
+        -- Deferrable so a reorder can swap positions in one statement.
+        UNIQUE (user_id, position) DEFERRABLE
+    );
+
+CREATE INDEX pinned_chirps_chirp_id_idx ON pinned_chirps (chirp_id);
+
+-- +goose Down
+DROP TABLE pinned_chirps;
\ No newline at end of file
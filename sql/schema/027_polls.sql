@@ -0,0 +1,43 @@
+-- +goose Up
+CREATE TABLE
+    polls (
+        chirp_id UUID PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+        created_at TIMESTAMP NOT NULL,
+        closes_at TIMESTAMP NOT NULL,
+        -- Set once the poll has closed and its tallies have been recounted
+        -- from the votes. They don't change after that.
+        finalized_at TIMESTAMP,
+        vote_count INTEGER NOT NULL DEFAULT 0
+    );
+
+CREATE INDEX polls_closes_at_idx ON polls (closes_at)
+WHERE finalized_at IS NULL;
+
+CREATE TABLE
+    poll_options (
+        chirp_id UUID NOT NULL REFERENCES polls (chirp_id) ON DELETE CASCADE,
+        position INTEGER NOT NULL CHECK (position BETWEEN 1 AND 4),
+        text TEXT NOT NULL,
+        vote_count INTEGER NOT NULL DEFAULT 0,
+        PRIMARY KEY (chirp_id, position)
+    );
+
+-- The primary key allows each user one vote per poll.
+CREATE TABLE
+    poll_votes (
+        chirp_id UUID NOT NULL,
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        position INTEGER NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (chirp_id, user_id),
+        FOREIGN KEY (chirp_id, position) REFERENCES poll_options (chirp_id, position) ON DELETE CASCADE
+    );
+
+CREATE INDEX poll_votes_user_id_idx ON poll_votes (user_id);
+
+-- +goose Down
+DROP TABLE poll_votes;
+
+DROP TABLE poll_options;
+
+DROP TABLE polls;
\ No newline at end of file
@@ -0,0 +1,76 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN expires_at TIMESTAMP;
+
+CREATE INDEX chirps_expires_at_idx ON chirps (expires_at)
+WHERE expires_at IS NOT NULL;
+
+-- Chirps older than this are deleted by the reaper. NULL keeps them forever.
+ALTER TABLE users
+ADD COLUMN auto_delete_after_days INTEGER CHECK (auto_delete_after_days > 0);
+
+-- Expired chirps are gone as far as readers are concerned, author included,
+-- even before the reaper gets to them.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to (chirp chirps, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT (chirp.expires_at IS NULL OR chirp.expires_at > NOW ())
+        AND (
+            (viewer_id IS NOT NULL AND chirp.user_id = viewer_id)
+            OR (chirp.status = 'published'
+                AND NOT EXISTS (
+                    SELECT 1 FROM blocks
+                    WHERE (blocks.blocker_id = chirp.user_id AND blocks.blocked_id = viewer_id)
+                        OR (blocks.blocker_id = viewer_id AND blocks.blocked_id = chirp.user_id)
+                )
+                AND (
+                    chirp.visibility = 'public'
+                    OR (chirp.visibility = 'followers' AND EXISTS (
+                        SELECT 1 FROM follows
+                        WHERE follows.follower_id = viewer_id
+                            AND follows.followee_id = chirp.user_id
+                    ))
+                    OR (chirp.visibility IN ('followers', 'mentioned') AND EXISTS (
+                        SELECT 1 FROM chirp_mentions
+                        WHERE chirp_mentions.chirp_id = chirp.id
+                            AND chirp_mentions.user_id = viewer_id
+                    ))
+                ))
+        )
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to (chirp chirps, viewer_id UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT (viewer_id IS NOT NULL AND chirp.user_id = viewer_id)
+        OR (chirp.status = 'published'
+            AND NOT EXISTS (
+                SELECT 1 FROM blocks
+                WHERE (blocks.blocker_id = chirp.user_id AND blocks.blocked_id = viewer_id)
+                    OR (blocks.blocker_id = viewer_id AND blocks.blocked_id = chirp.user_id)
+            )
+            AND (
+                chirp.visibility = 'public'
+                OR (chirp.visibility = 'followers' AND EXISTS (
+                    SELECT 1 FROM follows
+                    WHERE follows.follower_id = viewer_id
+                        AND follows.followee_id = chirp.user_id
+                ))
+                OR (chirp.visibility IN ('followers', 'mentioned') AND EXISTS (
+                    SELECT 1 FROM chirp_mentions
+                    WHERE chirp_mentions.chirp_id = chirp.id
+                        AND chirp_mentions.user_id = viewer_id
+                ))
+            ))
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE users
+DROP COLUMN auto_delete_after_days;
+
+DROP INDEX chirps_expires_at_idx;
+
+ALTER TABLE chirps
+DROP COLUMN expires_at;
\ No newline at end of file
@@ -0,0 +1,78 @@
+-- +goose Up
+CREATE TABLE
+    subscriptions (
+        id UUID PRIMARY KEY,
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL,
+        plan TEXT NOT NULL CHECK (plan IN ('chirpy_red')),
+        status TEXT NOT NULL CHECK (
+            status IN ('trialing', 'active', 'past_due', 'canceled', 'expired', 'lifetime')
+        ),
+        current_period_start TIMESTAMP NOT NULL,
+        current_period_end TIMESTAMP NOT NULL,
+        trial_ends_at TIMESTAMP,
+        grace_period_ends_at TIMESTAMP NOT NULL,
+        canceled_at TIMESTAMP,
+        ended_at TIMESTAMP
+    );
+
+-- Expired subscriptions are kept for the record, but a user has at most one
+-- that hasn't run out.
+CREATE UNIQUE INDEX subscriptions_user_id_current_idx ON subscriptions (user_id)
+WHERE status <> 'expired';
+
+-- Polka retries a webhook until it's acknowledged, so the same event can
+-- arrive more than once. Each one is recorded so it's only applied once.
+CREATE TABLE
+    polka_events (
+        id TEXT PRIMARY KEY,
+        user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        event TEXT NOT NULL,
+        received_at TIMESTAMP NOT NULL
+    );
+
+-- Chirpy Red used to be a one-off upgrade that never ran out, so users who
+-- have it keep it for good. Their period columns mean nothing.
+INSERT INTO
+    subscriptions (
+        id,
+        user_id,
+        created_at,
+        updated_at,
+        plan,
+        status,
+        current_period_start,
+        current_period_end,
+        grace_period_ends_at
+    )
+SELECT
+    gen_random_uuid (),
+    id,
+    NOW (),
+    NOW (),
+    'chirpy_red',
+    'lifetime',
+    NOW (),
+    NOW (),
+    NOW ()
+FROM users
+WHERE is_chirpy_red;
+
+ALTER TABLE users
+DROP COLUMN is_chirpy_red;
+
+-- +goose Down
+ALTER TABLE users
+ADD COLUMN is_chirpy_red BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users
+SET is_chirpy_red = TRUE
+WHERE id IN (
+        SELECT user_id FROM subscriptions
+        WHERE status <> 'expired'
+    );
+
+DROP TABLE polka_events;
+
+DROP TABLE subscriptions;
\ No newline at end of file
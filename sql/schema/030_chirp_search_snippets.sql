@@ -0,0 +1,19 @@
+-- +goose Up
+-- chirp_search_snippet highlights the words of a chirp that match a search
+-- query. It's HTML: the body is escaped first, so the <mark> tags around the
+-- matches are the only markup in it.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_search_snippet (body TEXT, query TSQUERY) RETURNS TEXT
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT ts_headline(
+        'english',
+        replace(replace(replace(replace(replace(body,
+            '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+        query,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2'
+    )
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_search_snippet;
\ No newline at end of file
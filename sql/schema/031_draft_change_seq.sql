@@ -0,0 +1,26 @@
+-- +goose Up
+-- Drafts sync in the order they changed. updated_at can't serve for that: a
+-- change can commit after a later-stamped one a client has already seen.
+CREATE SEQUENCE draft_change_seq;
+
+ALTER TABLE drafts
+ADD COLUMN change_seq BIGINT NOT NULL DEFAULT nextval('draft_change_seq');
+
+DROP INDEX drafts_user_id_updated_at_idx;
+
+CREATE INDEX drafts_user_id_change_seq_idx ON drafts (user_id, change_seq);
+
+CREATE INDEX drafts_deleted_at_idx ON drafts (deleted_at)
+WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX drafts_deleted_at_idx;
+
+DROP INDEX drafts_user_id_change_seq_idx;
+
+CREATE INDEX drafts_user_id_updated_at_idx ON drafts (user_id, updated_at, id);
+
+ALTER TABLE drafts
+DROP COLUMN change_seq;
+
+DROP SEQUENCE draft_change_seq;
\ No newline at end of file
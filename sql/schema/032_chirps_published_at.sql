@@ -0,0 +1,41 @@
+-- +goose Up
+-- When a chirp went out, which is when it was written unless it was
+-- scheduled or held. Timelines are ordered by it, so a scheduled chirp shows
+-- up as new, while created_at keeps when it was written. Until a chirp goes
+-- out it's the same as created_at.
+ALTER TABLE chirps
+ADD COLUMN published_at TIMESTAMP;
+
+UPDATE chirps
+SET published_at = created_at;
+
+ALTER TABLE chirps
+ALTER COLUMN published_at SET NOT NULL;
+
+DROP INDEX chirps_created_at_id_idx;
+
+DROP INDEX chirps_user_id_created_at_id_idx;
+
+DROP INDEX chirps_parent_chirp_id_created_at_id_idx;
+
+CREATE INDEX chirps_published_at_id_idx ON chirps (published_at, id);
+
+CREATE INDEX chirps_user_id_published_at_id_idx ON chirps (user_id, published_at, id);
+
+CREATE INDEX chirps_parent_chirp_id_published_at_id_idx ON chirps (parent_chirp_id, published_at, id);
+
+-- +goose Down
+DROP INDEX chirps_parent_chirp_id_published_at_id_idx;
+
+DROP INDEX chirps_user_id_published_at_id_idx;
+
+DROP INDEX chirps_published_at_id_idx;
+
+CREATE INDEX chirps_parent_chirp_id_created_at_id_idx ON chirps (parent_chirp_id, created_at, id);
+
+CREATE INDEX chirps_user_id_created_at_id_idx ON chirps (user_id, created_at, id);
+
+CREATE INDEX chirps_created_at_id_idx ON chirps (created_at, id);
+
+ALTER TABLE chirps
+DROP COLUMN published_at;
\ No newline at end of file
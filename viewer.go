@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/katsuikeda/chirpy/internal/auth"
+)
+
+// getViewerID identifies the caller on endpoints where signing in is
+// optional. A request without an Authorization header is anonymous, but a
+// header carrying a bad token is still an error.
+func (cfg *apiConfig) getViewerID(r *http.Request) (uuid.NullUUID, error) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	token, err := auth.GetAccessToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: true}, nil
+}
+
+// middlewareRejectSuspended turns away every write made with the access
+// token of a suspended account. Suspending an account revokes its refresh
+// tokens, but access tokens it already holds stay valid until they expire.
+// Requests without a valid access token are passed on for the handler to
+// deal with.
+func (cfg *apiConfig) middlewareRejectSuspended(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, err := auth.GetAccessToken(r.Header)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		suspended, err := cfg.db.IsUserSuspended(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+			return
+		}
+		if suspended {
+			respondWithError(w, http.StatusForbidden, "Account is suspended", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}